@@ -1,12 +1,20 @@
 package onesecmail
 
 import (
+	"context"
+	"crypto/rand"
 	"encoding/json"
+	"errors"
 	"fmt"
-	"io/ioutil"
+	"io"
+	"log/slog"
+	"math/big"
 	"net/http"
+	"net/url"
+	"regexp"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type mailboxAction int
@@ -17,11 +25,12 @@ const (
 	download
 	genRandomMailbox
 	getDomainList
+	deleteMessage
 )
 
 func (m mailboxAction) String() string {
 	return [...]string{
-		"getMessages", "readMessage", "download", "genRandomMailbox", "getDomainList",
+		"getMessages", "readMessage", "download", "genRandomMailbox", "getDomainList", "deleteMessage",
 	}[m]
 }
 
@@ -31,10 +40,14 @@ type Mail struct {
 	From        string       `json:"from"`
 	Subject     string       `json:"subject"`
 	Date        string       `json:"date"`
-	Attachments []Attachment `json:"attachments,omitempty"`
+	Attachments []Attachment `json:"attachments"`
 	Body        *string      `json:"body,omitempty"`
 	TextBody    *string      `json:"textBody,omitempty"`
 	HTMLBody    *string      `json:"htmlBody,omitempty"`
+
+	// ParsedDate is the Date parsed by ParseDate when the mail is decoded from JSON.
+	// It is zero if the Date cannot be parsed.
+	ParsedDate time.Time `json:"-"`
 }
 
 // Attachment represents an attachment in a 1secmail mail.
@@ -42,6 +55,12 @@ type Attachment struct {
 	Filename    string `json:"filename"`
 	ContentType string `json:"contentType"`
 	Size        int    `json:"size"`
+
+	// Content is the content of the attachment, if the API includes it in the mail as
+	// base64. It is nil if the content is not included, and must be downloaded with
+	// DownloadAttachment, and empty but not nil if the attachment is empty. It is
+	// encoded as null if it is nil, so that both survive a round trip through JSON.
+	Content []byte `json:"content"`
 }
 
 // HTTPClient is an interface that makes an HTTP request.
@@ -52,56 +71,136 @@ type HTTPClient interface {
 // API manages communication with the 1secmail's APIs that do not belong to a specific mailbox.
 type API struct {
 	client HTTPClient
+	cfg    *config
 }
 
-// NewAPI returns a new API. If nil httpClient is provided, a new http.Client will be created.
-func NewAPI(httpClient HTTPClient) API {
-	if httpClient == nil {
-		httpClient = http.DefaultClient
-	}
-	return API{client: httpClient}
+// NewAPI returns a new API configured by opts. Without options, requests are
+// made to the 1secmail API using http.DefaultClient. The base URL of the API is
+// the one set by WithBaseURL, or else the ONESECMAIL_BASE_URL environment variable,
+// or else https://www.1secmail.com/api/v1/.
+func NewAPI(opts ...Option) API {
+	cfg := newConfig(opts)
+	return API{client: cfg.client(), cfg: cfg}
+}
+
+// CircuitBreaker returns the circuit breaker set by WithCircuitBreaker, or nil if
+// none is set.
+func (a API) CircuitBreaker() *CircuitBreaker {
+	return a.cfg.breaker
 }
 
-func (a API) RandomAddresses(count int) ([]string, error) {
-	req := a.constructRequest("GET", genRandomMailbox, map[string]string{
+// MaxRandomAddresses is the maximum number of addresses that the API generates at once.
+const MaxRandomAddresses = 10
+
+// RandomAddresses generates count random email addresses. If count is not between 1
+// and MaxRandomAddresses, ErrInvalidCount is returned without calling the API.
+func (a API) RandomAddresses(ctx context.Context, count int) ([]string, error) {
+	if count < 1 || count > MaxRandomAddresses {
+		return nil, fmt.Errorf("generate random mailbox failed: %w: %d, must be between 1 and %d",
+			ErrInvalidCount, count, MaxRandomAddresses)
+	}
+	req, err := a.constructRequest(ctx, "GET", genRandomMailbox, map[string]string{
 		"count": strconv.Itoa(count),
 	})
-	resp, err := a.client.Do(req)
-	if err != nil || (resp != nil && resp.StatusCode != 200) {
+	if err != nil {
 		return nil, fmt.Errorf("generate random mailbox failed: %w", err)
 	}
+	resp, err := a.do(req, "generate random mailbox failed")
+	if err != nil {
+		return nil, err
+	}
 	defer resp.Body.Close()
 
 	var list []string
-	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
-		return nil, fmt.Errorf("decode JSON failed: %w", err)
+	if err := a.decode(resp.Body, &list); err != nil {
+		return nil, err
 	}
 	return list, nil
 }
 
-func (a API) Domains() ([]string, error) {
-	req := a.constructRequest("GET", getDomainList, nil)
-	resp, err := a.client.Do(req)
-	if err != nil || (resp != nil && resp.StatusCode != 200) {
+// RandomMailbox generates a random email address, and returns a Mailbox for it that
+// uses the same HTTP client as a.
+func (a API) RandomMailbox(ctx context.Context) (Mailbox, error) {
+	addresses, err := a.RandomAddresses(ctx, 1)
+	if err != nil {
+		return Mailbox{}, err
+	}
+	if len(addresses) == 0 {
+		return Mailbox{}, fmt.Errorf("generate random mailbox failed: no address returned")
+	}
+	return a.mailbox(addresses[0])
+}
+
+// mailbox returns a Mailbox for an address generated by the API, that uses a.
+func (a API) mailbox(address string) (Mailbox, error) {
+	login, domain, ok := strings.Cut(address, "@")
+	if !ok || login == "" || domain == "" {
+		return Mailbox{}, fmt.Errorf("%w: %s", ErrInvalidAddress, address)
+	}
+	return Mailbox{
+		API:    a,
+		Domain: domain,
+		Login:  login,
+	}, nil
+}
+
+// Domains returns the live list of domains that 1secmail supports. If
+// WithDomainCacheTTL is set, the list is cached, and returned until the TTL expires.
+func (a API) Domains(ctx context.Context) ([]string, error) {
+	cache := a.cfg.domainCache
+	if cache == nil {
+		return a.fetchDomains(ctx)
+	}
+	if domains, ok := cache.get(); ok {
+		return domains, nil
+	}
+	domains, err := a.fetchDomains(ctx)
+	if err != nil {
+		return nil, err
+	}
+	cache.set(domains)
+	return domains, nil
+}
+
+// fetchDomains returns the live list of domains from the API, bypassing the cache.
+func (a API) fetchDomains(ctx context.Context) ([]string, error) {
+	req, err := a.constructRequest(ctx, "GET", getDomainList, nil)
+	if err != nil {
 		return nil, fmt.Errorf("get domain list failed: %w", err)
 	}
+	resp, err := a.do(req, "get domain list failed")
+	if err != nil {
+		return nil, err
+	}
 	defer resp.Body.Close()
 
 	var list []string
-	if err := json.NewDecoder(resp.Body).Decode(&list); err != nil {
-		return nil, fmt.Errorf("decode JSON failed: %w", err)
+	if err := a.decode(resp.Body, &list); err != nil {
+		return nil, err
 	}
 	return list, nil
 }
 
 // UpdateDomains updates the list of domains that 1secmail supports.
-// This is useful if the list of domains have changed since this library was last updated.
-func (a API) UpdateDomains() error {
+//
+// Deprecated: Use RefreshDomains instead.
+func (a API) UpdateDomains(ctx context.Context) error {
+	return a.RefreshDomains(ctx)
+}
+
+// RefreshDomains replaces the list of domains that 1secmail supports with the live
+// list from the API. This is useful if the list of domains have changed since this
+// library was last updated. The list is always fetched from the API, and replaces
+// the one cached by Domains, if any.
+func (a API) RefreshDomains(ctx context.Context) error {
 	domains := make(map[string]struct{})
-	liveDomains, err := a.Domains()
+	liveDomains, err := a.fetchDomains(ctx)
 	if err != nil {
 		return err
 	}
+	if a.cfg.domainCache != nil {
+		a.cfg.domainCache.set(liveDomains)
+	}
 	for _, domain := range liveDomains {
 		domains[domain] = struct{}{}
 	}
@@ -123,15 +222,98 @@ func (m Mailbox) Address() string {
 	return fmt.Sprintf("%s@%s", m.Login, m.Domain)
 }
 
+// String returns the email address of a Mailbox, as Address does.
+func (m Mailbox) String() string {
+	return m.Address()
+}
+
+// URIScheme is the scheme of the URI of a Mailbox, as in 1secmail://login@domain.
+const URIScheme = "1secmail"
+
+// URI returns the URI of a Mailbox, e.g. 1secmail://foo@1secmail.com. It can be
+// parsed by NewMailboxFromURI.
+func (m Mailbox) URI() string {
+	u := url.URL{Scheme: URIScheme, User: url.User(m.Login), Host: m.Domain}
+	return u.String()
+}
+
+// NewMailboxFromURI returns a new Mailbox for a URI of the form
+// 1secmail://login@domain, as returned by URI. The URI may not contain a password,
+// since 1secmail mailboxes have none, or a port, path, query or fragment. The
+// Mailbox is configured by opts, as in NewAPI.
+func NewMailboxFromURI(uri string, opts ...Option) (Mailbox, error) {
+	// url.Parse rejects schemes starting with a digit, so only the rest is parsed.
+	rest, ok := strings.CutPrefix(uri, URIScheme+"://")
+	if !ok {
+		return Mailbox{}, fmt.Errorf("%w: scheme must be %s: %q", ErrInvalidAddress, URIScheme, uri)
+	}
+	u, err := url.Parse("//" + rest)
+	if err != nil {
+		return Mailbox{}, fmt.Errorf("%w: %w", ErrInvalidAddress, err)
+	}
+	if u.User == nil || u.Hostname() == "" {
+		return Mailbox{}, fmt.Errorf("%w: missing login or domain: %q", ErrInvalidAddress, uri)
+	}
+	if _, ok := u.User.Password(); ok {
+		return Mailbox{}, fmt.Errorf("%w: password not supported: %q", ErrInvalidAddress, uri)
+	}
+	if u.Port() != "" || (u.Path != "" && u.Path != "/") || u.RawQuery != "" || u.Fragment != "" {
+		return Mailbox{}, fmt.Errorf("%w: unexpected port, path, query or fragment: %q", ErrInvalidAddress, uri)
+	}
+	return NewMailbox(u.User.Username(), u.Hostname(), opts...)
+}
+
+// mailboxJSON is the JSON representation of a Mailbox.
+type mailboxJSON struct {
+	Login  string `json:"login"`
+	Domain string `json:"domain"`
+}
+
+// MarshalJSON encodes the login and domain of a Mailbox, e.g.
+// {"login":"foo","domain":"1secmail.com"}. Its client and options are not encoded.
+func (m Mailbox) MarshalJSON() ([]byte, error) {
+	return json.Marshal(mailboxJSON{Login: m.Login, Domain: m.Domain})
+}
+
+// UnmarshalJSON decodes a Mailbox encoded by MarshalJSON, and validates it as
+// NewMailbox does. The decoded Mailbox uses http.DefaultClient and the default
+// options; use NewMailboxFromJSON to set the client.
+func (m *Mailbox) UnmarshalJSON(data []byte) error {
+	mailbox, err := NewMailboxFromJSON(data, nil)
+	if err != nil {
+		return err
+	}
+	*m = mailbox
+	return nil
+}
+
+// NewMailboxFromJSON returns a new Mailbox decoded from data, as encoded by
+// MarshalJSON, that makes requests with httpClient. If httpClient is nil,
+// http.DefaultClient is used.
+func NewMailboxFromJSON(data []byte, httpClient HTTPClient) (Mailbox, error) {
+	var v mailboxJSON
+	if err := json.Unmarshal(data, &v); err != nil {
+		return Mailbox{}, fmt.Errorf("%w: %w", ErrDecodeJSON, err)
+	}
+	return NewMailbox(v.Login, v.Domain, WithHTTPClient(httpClient))
+}
+
+// loginRe matches the logins accepted by NewMailbox.
+var loginRe = regexp.MustCompile(`^[A-Za-z0-9._-]+$`)
+
 // NewMailbox returns a new Mailbox. Use login and domain for the email
-// handler that you intend to use. Login is the email username.
-// If nil httpClient is provided, a new http.Client will be created.
-func NewMailbox(login, domain string, httpClient HTTPClient) (Mailbox, error) {
-	if _, ok := Domains[domain]; !ok {
-		return Mailbox{}, fmt.Errorf("invalid domain: %s", domain)
+// handler that you intend to use. Login is the email username, and may
+// only contain ASCII letters, digits, dots, hyphens and underscores.
+// The Mailbox is configured by opts, as in NewAPI.
+func NewMailbox(login, domain string, opts ...Option) (Mailbox, error) {
+	if !loginRe.MatchString(login) {
+		return Mailbox{}, fmt.Errorf("%w: invalid login: %q", ErrInvalidAddress, login)
+	}
+	if !IsDomain(domain) {
+		return Mailbox{}, fmt.Errorf("%w: %s", ErrInvalidDomain, domain)
 	}
 	return Mailbox{
-		API:    NewAPI(httpClient),
+		API:    NewAPI(opts...),
 		Domain: domain,
 		Login:  login,
 	}, nil
@@ -139,85 +321,365 @@ func NewMailbox(login, domain string, httpClient HTTPClient) (Mailbox, error) {
 
 // NewMailboxWithAddress returns a new Mailbox. It accepts an email address
 // that refers to a 1secmail mailbox. This is easier to use than NewMailbox
-// if you already have an email address. If nil httpClient is provided, a
-// new http.Client will be created.
-func NewMailboxWithAddress(address string, httpClient HTTPClient) (Mailbox, error) {
+// if you already have an email address. The Mailbox is configured by opts,
+// as in NewAPI.
+func NewMailboxWithAddress(address string, opts ...Option) (Mailbox, error) {
 	login, domain, ok := strings.Cut(address, "@")
 	if !ok || login == "" || domain == "" {
-		return Mailbox{}, fmt.Errorf("invalid email address: %s", address)
+		return Mailbox{}, fmt.Errorf("%w: %s", ErrInvalidAddress, address)
 	}
-	return NewMailbox(login, domain, httpClient)
+	return NewMailbox(login, domain, opts...)
+}
+
+// randomLoginLength is the length of the login of a mailbox created by NewRandomMailbox.
+const randomLoginLength = 10
+
+// RandomLogin returns a random login of n lowercase letters and digits, generated
+// locally with crypto/rand. It returns an empty string if n is not positive.
+func RandomLogin(n int) string {
+	const chars = "abcdefghijklmnopqrstuvwxyz0123456789"
+	if n <= 0 {
+		return ""
+	}
+	b := make([]byte, n)
+	for i := range b {
+		idx, err := rand.Int(rand.Reader, big.NewInt(int64(len(chars))))
+		if err != nil {
+			panic(fmt.Sprintf("onesecmail: read random failed: %v", err))
+		}
+		b[i] = chars[idx.Int64()]
+	}
+	return string(b)
+}
+
+// NewRandomMailbox returns a new Mailbox with a random login on domain, without
+// calling the API. The Mailbox is configured by opts, as in NewAPI.
+func NewRandomMailbox(domain string, opts ...Option) (Mailbox, error) {
+	return NewMailbox(RandomLogin(randomLoginLength), domain, opts...)
+}
+
+// WithLogin returns a copy of m with the login replaced. The copy shares the client
+// and options of m, and the domain is not checked again.
+func (m Mailbox) WithLogin(login string) (Mailbox, error) {
+	if !loginRe.MatchString(login) {
+		return Mailbox{}, fmt.Errorf("%w: invalid login: %q", ErrInvalidAddress, login)
+	}
+	m.Login = login
+	return m, nil
+}
+
+// Clone returns a copy of m with the same login, domain, client and options. The
+// copy has its own headers, circuit breaker, domain cache, and rate limiter set by
+// WithRateLimit, so that the state of one does not affect the other. It shares the
+// HTTP client, the logger, observer, metrics and tracer provider, and a rate limiter
+// set by WithRateLimiter.
+func (m Mailbox) Clone() Mailbox {
+	cfg := m.cfg.clone()
+	m.API = API{client: cfg.client(), cfg: cfg}
+	return m
+}
+
+// WithClient returns a copy of m, as returned by Clone, that makes requests with c
+// instead. The options of m, such as retries, apply to the requests made with c.
+// If c is nil, http.DefaultClient is used.
+func (m Mailbox) WithClient(c HTTPClient) Mailbox {
+	cfg := m.cfg.clone()
+	cfg.httpClient = c
+	if c == nil {
+		cfg.httpClient = http.DefaultClient
+	}
+	m.API = API{client: cfg.client(), cfg: cfg}
+	return m
 }
 
 // CheckInbox checks the inbox of a mailbox, and returns a list of mails.
-func (m Mailbox) CheckInbox() ([]*Mail, error) {
-	req := m.constructRequest("GET", getMessages, map[string]string{
+func (m Mailbox) CheckInbox(ctx context.Context) ([]*Mail, error) {
+	req, err := m.constructRequest(ctx, "GET", getMessages, map[string]string{
 		"login":  m.Login,
 		"domain": m.Domain,
 	})
-	resp, err := m.client.Do(req)
-	if err != nil || (resp != nil && resp.StatusCode != 200) {
-		return nil, fmt.Errorf("check inbox failed: %w, error code: %v", err, resp.StatusCode)
+	if err != nil {
+		return nil, fmt.Errorf("check inbox failed: %w", err)
+	}
+	resp, err := m.do(req, "check inbox failed")
+	if err != nil {
+		return nil, err
 	}
 	defer resp.Body.Close()
 
 	var mails []*Mail
-	if err := json.NewDecoder(resp.Body).Decode(&mails); err != nil {
-		return nil, fmt.Errorf("decode JSON failed: %w", err)
+	if err := m.decode(resp.Body, &mails); err != nil {
+		return nil, err
 	}
 	return mails, nil
 }
 
+// CountMessages returns the number of mails in the inbox of a mailbox.
+func (m Mailbox) CountMessages(ctx context.Context) (int, error) {
+	mails, err := m.CheckInbox(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return len(mails), nil
+}
+
+// CheckInboxN checks the inbox of a mailbox, and returns at most limit mails, newest
+// first. The API always returns the whole inbox, so the mails are limited after they
+// are fetched. If limit is zero or negative, all mails are returned.
+func (m Mailbox) CheckInboxN(ctx context.Context, limit int) ([]*Mail, error) {
+	mails, err := m.CheckInbox(ctx)
+	if err != nil {
+		return nil, err
+	}
+	SortByDate(mails, false)
+	if limit > 0 && len(mails) > limit {
+		mails = mails[:limit]
+	}
+	return mails, nil
+}
+
+// NewMessagesSince checks the inbox of a mailbox, and returns the mails with an ID
+// greater than lastID, lowest ID first. Pass the ID of the last mail processed to
+// get only the mails that arrived since. Mails are not returned again if the inbox
+// is emptied, or if its mails are given lower IDs than lastID.
+func (m Mailbox) NewMessagesSince(ctx context.Context, lastID int) ([]*Mail, error) {
+	mails, err := m.CheckInbox(ctx)
+	if err != nil {
+		return nil, err
+	}
+	newMails := make([]*Mail, 0, len(mails))
+	for _, mail := range mails {
+		if mail.ID > lastID {
+			newMails = append(newMails, mail)
+		}
+	}
+	SortByID(newMails, true)
+	return newMails, nil
+}
+
+// LatestMessage reads the latest mail in the inbox of a mailbox, which is the one
+// with the latest date, or the highest ID among mails with the same date. If the
+// inbox is empty, ErrNoMessages is returned.
+func (m Mailbox) LatestMessage(ctx context.Context) (*Mail, error) {
+	mails, err := m.CheckInbox(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(mails) == 0 {
+		return nil, ErrNoMessages
+	}
+	SortByDate(mails, false)
+	return m.ReadMessage(ctx, mails[0].ID)
+}
+
+// Oldest reads the mail with the lowest ID in the inbox of a mailbox. If the inbox
+// is empty, ErrEmptyInbox is returned.
+func (m Mailbox) Oldest(ctx context.Context) (*Mail, error) {
+	return m.readFirstByID(ctx, true)
+}
+
+// Newest reads the mail with the highest ID in the inbox of a mailbox. If the inbox
+// is empty, ErrEmptyInbox is returned.
+func (m Mailbox) Newest(ctx context.Context) (*Mail, error) {
+	return m.readFirstByID(ctx, false)
+}
+
+// readFirstByID reads the first mail in the inbox of a mailbox sorted by ID.
+func (m Mailbox) readFirstByID(ctx context.Context, ascending bool) (*Mail, error) {
+	mails, err := m.CheckInbox(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(mails) == 0 {
+		return nil, ErrEmptyInbox
+	}
+	SortByID(mails, ascending)
+	return m.ReadMessage(ctx, mails[0].ID)
+}
+
 // ReadMessage retrieves a particular mail from the inbox of a mailbox.
-func (m Mailbox) ReadMessage(messageID int) (*Mail, error) {
-	req := m.constructRequest("GET", readMessage, map[string]string{
+func (m Mailbox) ReadMessage(ctx context.Context, messageID int) (*Mail, error) {
+	req, err := m.constructRequest(ctx, "GET", readMessage, map[string]string{
 		"login":  m.Login,
 		"domain": m.Domain,
 		"id":     strconv.Itoa(messageID),
 	})
-	resp, err := m.client.Do(req)
-	if err != nil || (resp != nil && resp.StatusCode != 200) {
+	if err != nil {
 		return nil, fmt.Errorf("read message failed: %w", err)
 	}
+	resp, err := m.do(req, "read message failed")
+	if err != nil {
+		return nil, err
+	}
 	defer resp.Body.Close()
 
 	var mail *Mail
-	if err := json.NewDecoder(resp.Body).Decode(&mail); err != nil {
-		return nil, fmt.Errorf("decode JSON failed: %w", err)
+	if err := m.decode(resp.Body, &mail); err != nil {
+		return nil, err
 	}
 
 	return mail, nil
 }
 
-func (m Mailbox) DownloadAttachment(messageID int, filename string) ([]byte, error) {
-	req := m.constructRequest("GET", download, map[string]string{
+// DeleteMessage deletes a particular mail from the inbox of a mailbox. It uses the
+// undocumented deleteMessage action of the 1secmail API. If there is no such mail,
+// the returned error wraps ErrMessageNotFound.
+func (m Mailbox) DeleteMessage(ctx context.Context, messageID int) error {
+	req, err := m.constructRequest(ctx, "GET", deleteMessage, map[string]string{
+		"login":  m.Login,
+		"domain": m.Domain,
+		"id":     strconv.Itoa(messageID),
+	})
+	if err != nil {
+		return fmt.Errorf("delete message failed: %w", err)
+	}
+	resp, err := m.do(req, "delete message failed")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	_, err = io.Copy(io.Discard, resp.Body)
+	return err
+}
+
+// Empty deletes every mail in the inbox of a mailbox with DeleteMessage, and returns
+// the number of mails deleted. It keeps going if a mail cannot be deleted, and
+// returns the errors joined with errors.Join. Mails that are already gone are not
+// counted, and are not errors.
+func (m Mailbox) Empty(ctx context.Context) (int, error) {
+	mails, err := m.CheckInbox(ctx)
+	if err != nil {
+		return 0, err
+	}
+	deleted := 0
+	var errs []error
+	for _, mail := range mails {
+		err := m.DeleteMessage(ctx, mail.ID)
+		switch {
+		case err == nil:
+			deleted++
+		case !errors.Is(err, ErrMessageNotFound):
+			errs = append(errs, fmt.Errorf("message %d: %w", mail.ID, err))
+		}
+	}
+	return deleted, errors.Join(errs...)
+}
+
+// DownloadAttachment downloads an attachment of a mail, and returns a stream of its raw content.
+// The filename is the Filename of one of the mail's Attachments.
+// The caller is responsible for closing the returned io.ReadCloser.
+func (m Mailbox) DownloadAttachment(ctx context.Context, messageID int, filename string) (io.ReadCloser, error) {
+	if messageID == 0 {
+		return nil, fmt.Errorf("download attachment failed: invalid message ID: %d", messageID)
+	}
+	if filename == "" {
+		return nil, fmt.Errorf("download attachment failed: empty filename")
+	}
+	req, err := m.constructRequest(ctx, "GET", download, map[string]string{
 		"login":  m.Login,
 		"domain": m.Domain,
 		"id":     strconv.Itoa(messageID),
 		"file":   filename,
 	})
-	resp, err := m.client.Do(req)
-	if err != nil || (resp != nil && resp.StatusCode != 200) {
+	if err != nil {
 		return nil, fmt.Errorf("download attachment failed: %w", err)
 	}
-	defer resp.Body.Close()
+	resp, err := m.do(req, "download attachment failed")
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
 
-	data, err := ioutil.ReadAll(resp.Body)
+// do sends a request constructed by constructRequest. If the request fails or the
+// response status code is not 200, an *APIError described by msg is returned, and
+// the response body is closed. Otherwise the caller must close the response body.
+func (a API) do(req *http.Request, msg string) (*http.Response, error) {
+	action := req.URL.Query().Get("action")
+	cancel := context.CancelFunc(func() {})
+	if a.cfg.timeout > 0 {
+		var ctx context.Context
+		ctx, cancel = context.WithTimeout(req.Context(), a.cfg.timeout)
+		req = req.WithContext(ctx)
+	}
+	req, span := a.startSpan(req, action)
+	ctx := req.Context()
+	a.log(ctx, slog.LevelDebug, "sending request", slog.String("action", action),
+		slog.String("method", req.Method), slog.String("url", req.URL.String()))
+	start := time.Now()
+	resp, err := a.client.Do(req)
+	latency := time.Since(start)
+	endSpan(span, resp, err)
 	if err != nil {
-		return nil, fmt.Errorf("read response body failed: %w", err)
+		cancel()
+		a.log(ctx, slog.LevelWarn, "request failed", slog.String("action", action), slog.String("url", req.URL.String()),
+			slog.Duration("latency", latency), slog.Any("error", err))
+		a.observe(action, 0, latency)
+		a.cfg.metrics.RecordRequestDuration(action, 0, latency)
+		a.cfg.metrics.IncrementErrorCount(action)
+		return nil, &APIError{Action: action, Message: msg, Err: err}
 	}
-	return data, nil
+	a.log(ctx, slog.LevelDebug, "request done", slog.String("action", action), slog.String("url", req.URL.String()),
+		slog.Int("status", resp.StatusCode), slog.Duration("latency", latency))
+	a.observe(action, resp.StatusCode, latency)
+	a.cfg.metrics.RecordRequestDuration(action, resp.StatusCode, latency)
+	if resp.StatusCode != 200 {
+		a.cfg.metrics.IncrementErrorCount(action)
+		a.log(ctx, slog.LevelWarn, "unexpected status", slog.String("action", action), slog.String("url", req.URL.String()),
+			slog.Int("status", resp.StatusCode), slog.Duration("latency", latency))
+		body, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBodySize))
+		resp.Body.Close()
+		cancel()
+		return nil, &APIError{
+			StatusCode: resp.StatusCode,
+			Action:     action,
+			Message:    msg,
+			Body:       string(body),
+			Err:        statusError(action, resp.StatusCode),
+		}
+	}
+	resp.Body = cancelOnClose{ReadCloser: resp.Body, cancel: cancel}
+	return resp, nil
 }
 
-func (a API) constructRequest(method string, action mailboxAction, args map[string]string) *http.Request {
-	const apiBase = "https://www.1secmail.com/api/v1/"
+// decode decodes the JSON response body into v. At most the maximum response size
+// set by WithMaxResponseBytes is read.
+func (a API) decode(body io.Reader, v interface{}) error {
+	var lr *io.LimitedReader
+	if a.cfg.maxResponseBytes > 0 {
+		lr = &io.LimitedReader{R: body, N: a.cfg.maxResponseBytes}
+		body = lr
+	}
+	if err := json.NewDecoder(body).Decode(v); err != nil {
+		if lr != nil && lr.N <= 0 {
+			return fmt.Errorf("%w: %w: limit is %d bytes", ErrDecodeJSON, ErrResponseTooLarge, a.cfg.maxResponseBytes)
+		}
+		return fmt.Errorf("%w: %w", ErrDecodeJSON, err)
+	}
+	return nil
+}
 
-	req, _ := http.NewRequest(method, apiBase, nil)
+// log logs a record with the logger set by WithLogger, if any.
+func (a API) log(ctx context.Context, level slog.Level, msg string, attrs ...slog.Attr) {
+	if a.cfg.logger != nil {
+		a.cfg.logger.LogAttrs(ctx, level, msg, attrs...)
+	}
+}
+
+func (a API) constructRequest(ctx context.Context, method string, action mailboxAction, args map[string]string) (*http.Request, error) {
+	req, err := http.NewRequestWithContext(ctx, method, a.cfg.baseURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", a.cfg.userAgent)
+	for key, values := range a.cfg.headers {
+		req.Header[key] = append([]string(nil), values...)
+	}
 	query := req.URL.Query()
 	query.Add("action", fmt.Sprint(action))
 	for k, v := range args {
 		query.Add(k, v)
 	}
 	req.URL.RawQuery = query.Encode()
-	return req
+	return req, nil
 }
@@ -0,0 +1,130 @@
+package onesecmail
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// HasAttachments reports whether the mail has any attachments.
+func (m *Mail) HasAttachments() bool {
+	return len(m.Attachments) > 0
+}
+
+// TotalAttachmentSize returns the total size of the attachments of the mail, or 0 if
+// it has none.
+func (m *Mail) TotalAttachmentSize() int {
+	size := 0
+	for _, attachment := range m.Attachments {
+		size += attachment.Size
+	}
+	return size
+}
+
+// AttachmentByName returns the first attachment of the mail whose Filename is name,
+// ignoring case, and whether there is one.
+func (m *Mail) AttachmentByName(name string) (*Attachment, bool) {
+	for i := range m.Attachments {
+		if strings.EqualFold(m.Attachments[i].Filename, name) {
+			return &m.Attachments[i], true
+		}
+	}
+	return nil, false
+}
+
+// SaveToFile downloads the attachment from the mail with messageID in mb, and saves
+// it to destPath. It is the same as calling mb.SaveAttachment with a's Filename.
+func (a Attachment) SaveToFile(ctx context.Context, mb Mailbox, messageID int, destPath string) error {
+	return mb.SaveAttachment(ctx, messageID, a.Filename, destPath)
+}
+
+// SaveAttachment downloads the attachment filename from the mail with messageID, and
+// saves it to destPath with 0600 permissions, creating parent directories as needed.
+// If destPath is an existing directory, the attachment is saved inside it under its
+// filename, stripped of any directory components. An existing file is truncated.
+// If the download or the write fails, the incomplete file is removed.
+func (m Mailbox) SaveAttachment(ctx context.Context, messageID int, filename, destPath string) error {
+	if info, err := os.Stat(destPath); err == nil && info.IsDir() {
+		destPath = filepath.Join(destPath, sanitizeFilename(filename))
+	}
+
+	body, err := m.DownloadAttachment(ctx, messageID, filename)
+	if err != nil {
+		return err
+	}
+	defer body.Close()
+
+	if err := os.MkdirAll(filepath.Dir(destPath), 0700); err != nil {
+		return fmt.Errorf("save attachment failed: %w", err)
+	}
+	f, err := os.OpenFile(destPath, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("save attachment failed: %w", err)
+	}
+	if _, err := io.Copy(f, body); err != nil {
+		f.Close()
+		os.Remove(destPath)
+		return fmt.Errorf("save attachment failed: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		os.Remove(destPath)
+		return fmt.Errorf("save attachment failed: %w", err)
+	}
+	return nil
+}
+
+// SaveAllAttachments reads the mail with messageID, and saves each of its attachments
+// into destDir as in SaveAttachment. Attachments whose names collide are saved with a
+// counter appended, e.g. "report-1.pdf". It returns the paths of the saved files. A
+// failure to save an attachment does not stop the others from being saved; all
+// failures are joined into the returned error.
+func (m Mailbox) SaveAllAttachments(ctx context.Context, messageID int, destDir string) ([]string, error) {
+	mail, err := m.ReadMessage(ctx, messageID)
+	if err != nil {
+		return nil, err
+	}
+
+	var paths []string
+	var errs []error
+	used := make(map[string]struct{})
+	for _, attachment := range mail.Attachments {
+		name := uniqueFilename(sanitizeFilename(attachment.Filename), used)
+		path := filepath.Join(destDir, name)
+		if err := m.SaveAttachment(ctx, messageID, attachment.Filename, path); err != nil {
+			errs = append(errs, fmt.Errorf("%s: %w", attachment.Filename, err))
+			continue
+		}
+		paths = append(paths, path)
+	}
+	return paths, errors.Join(errs...)
+}
+
+// uniqueFilename returns name, or name with a counter appended if it is already in
+// used, and marks the returned name as used.
+func uniqueFilename(name string, used map[string]struct{}) string {
+	ext := filepath.Ext(name)
+	base := strings.TrimSuffix(name, ext)
+	unique := name
+	for i := 1; ; i++ {
+		if _, ok := used[strings.ToLower(unique)]; !ok {
+			break
+		}
+		unique = fmt.Sprintf("%s-%d%s", base, i, ext)
+	}
+	used[strings.ToLower(unique)] = struct{}{}
+	return unique
+}
+
+// sanitizeFilename returns the base name of a filename provided by the API, so
+// that it cannot refer to a file outside of the directory it is saved in.
+func sanitizeFilename(filename string) string {
+	name := filepath.Base(strings.ReplaceAll(filename, `\`, "/"))
+	if name == "." || name == ".." || name == "/" || name == string(filepath.Separator) {
+		return "attachment"
+	}
+	return name
+}
@@ -0,0 +1,273 @@
+package onesecmail_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/z11i/onesecmail"
+)
+
+// failingReader returns some data, then fails.
+type failingReader struct{ done bool }
+
+func (r *failingReader) Read(p []byte) (int, error) {
+	if r.done {
+		return 0, errors.New("connection reset")
+	}
+	r.done = true
+	return copy(p, "partial"), nil
+}
+
+func Test_Attachment_SaveToFile(t *testing.T) {
+	tests := []struct {
+		name      string
+		body      func() *http.Response
+		intoDir   bool
+		expErr    bool
+		expExists bool
+	}{
+		{
+			name: "save to path",
+			body: func() *http.Response {
+				return &http.Response{StatusCode: 200, Body: ioutil.NopCloser(strings.NewReader("hello"))}
+			},
+			expExists: true,
+		},
+		{
+			name: "save into directory",
+			body: func() *http.Response {
+				return &http.Response{StatusCode: 200, Body: ioutil.NopCloser(strings.NewReader("hello"))}
+			},
+			intoDir:   true,
+			expExists: true,
+		},
+		{
+			name: "download failed",
+			body: func() *http.Response {
+				return &http.Response{StatusCode: 500, Body: ioutil.NopCloser(strings.NewReader(""))}
+			},
+			expErr: true,
+		},
+		{
+			name: "partial write removed",
+			body: func() *http.Response {
+				return &http.Response{StatusCode: 200, Body: ioutil.NopCloser(&failingReader{})}
+			},
+			expErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := &ClientMock{
+				DoFunc: func(req *http.Request) (*http.Response, error) { return test.body(), nil },
+			}
+			mailbox, err := onesecmail.NewMailbox("foo", "1secmail.org", onesecmail.WithHTTPClient(client))
+			if err != nil {
+				t.Fatal("should not error")
+			}
+			dir := t.TempDir()
+			attachment := onesecmail.Attachment{Filename: "report.txt", ContentType: "text/plain", Size: 5}
+			dest := filepath.Join(dir, "saved.txt")
+			expPath := dest
+			if test.intoDir {
+				dest = dir
+				expPath = filepath.Join(dir, "report.txt")
+			}
+
+			err = attachment.SaveToFile(context.Background(), mailbox, 639, dest)
+			if (err == nil) != !test.expErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			info, statErr := os.Stat(expPath)
+			if (statErr == nil) != test.expExists {
+				t.Fatalf("file existence expected: %v, got error: %v", test.expExists, statErr)
+			}
+			if !test.expExists {
+				return
+			}
+			if info.Mode().Perm() != 0600 {
+				t.Fatalf("permissions expected: 0600, got: %v", info.Mode().Perm())
+			}
+			data, _ := os.ReadFile(expPath)
+			if string(data) != "hello" {
+				t.Fatalf("content expected: hello, got: %s", data)
+			}
+		})
+	}
+}
+
+func Test_SaveAttachment(t *testing.T) {
+	tests := []struct {
+		name     string
+		filename string
+		dest     func(dir string) string
+		expPath  func(dir string) string
+	}{
+		{
+			name:     "creates parent directories",
+			filename: "report.txt",
+			dest:     func(dir string) string { return filepath.Join(dir, "a", "b", "saved.txt") },
+			expPath:  func(dir string) string { return filepath.Join(dir, "a", "b", "saved.txt") },
+		},
+		{
+			name:     "path traversal",
+			filename: "../../etc/passwd",
+			dest:     func(dir string) string { return dir },
+			expPath:  func(dir string) string { return filepath.Join(dir, "passwd") },
+		},
+		{
+			name:     "windows path traversal",
+			filename: `..\..\evil.txt`,
+			dest:     func(dir string) string { return dir },
+			expPath:  func(dir string) string { return filepath.Join(dir, "evil.txt") },
+		},
+		{
+			name:     "dot dot only",
+			filename: "..",
+			dest:     func(dir string) string { return dir },
+			expPath:  func(dir string) string { return filepath.Join(dir, "attachment") },
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var gotFile string
+			client := &ClientMock{
+				DoFunc: func(req *http.Request) (*http.Response, error) {
+					gotFile = req.URL.Query().Get("file")
+					return &http.Response{StatusCode: 200, Body: ioutil.NopCloser(strings.NewReader("hello"))}, nil
+				},
+			}
+			mailbox, err := onesecmail.NewMailbox("foo", "1secmail.org", onesecmail.WithHTTPClient(client))
+			if err != nil {
+				t.Fatal("should not error")
+			}
+			dir := t.TempDir()
+			if err := mailbox.SaveAttachment(context.Background(), 639, test.filename, test.dest(dir)); err != nil {
+				t.Fatalf("should not error: %v", err)
+			}
+			if gotFile != test.filename {
+				t.Fatalf("requested file expected: %s, got: %s", test.filename, gotFile)
+			}
+			data, err := os.ReadFile(test.expPath(dir))
+			if err != nil {
+				t.Fatalf("file should be saved: %v", err)
+			}
+			if string(data) != "hello" {
+				t.Fatalf("content expected: hello, got: %s", data)
+			}
+		})
+	}
+}
+
+func Test_SaveAllAttachments(t *testing.T) {
+	client := &ClientMock{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			query := req.URL.Query()
+			switch query.Get("action") {
+			case "readMessage":
+				return &http.Response{StatusCode: 200, Body: ioutil.NopCloser(strings.NewReader(`{"id":639,"from":"someone@example.com","subject":"Files","date":"2018-06-08 14:33:55","attachments":[
+					{"filename":"report.pdf","contentType":"application/pdf","size":5},
+					{"filename":"missing.png","contentType":"image/png","size":5},
+					{"filename":"dir/report.pdf","contentType":"application/pdf","size":5}
+				]}`))}, nil
+			case "download":
+				if query.Get("file") == "missing.png" {
+					return &http.Response{StatusCode: 404, Body: ioutil.NopCloser(strings.NewReader(""))}, nil
+				}
+				return &http.Response{StatusCode: 200, Body: ioutil.NopCloser(strings.NewReader(query.Get("file")))}, nil
+			}
+			return &http.Response{StatusCode: 400, Body: ioutil.NopCloser(strings.NewReader(""))}, nil
+		},
+	}
+	mailbox, err := onesecmail.NewMailbox("foo", "1secmail.org", onesecmail.WithHTTPClient(client))
+	if err != nil {
+		t.Fatal("should not error")
+	}
+	dir := t.TempDir()
+	paths, err := mailbox.SaveAllAttachments(context.Background(), 639, dir)
+	if !errors.Is(err, onesecmail.ErrMessageNotFound) || !strings.Contains(err.Error(), "missing.png") {
+		t.Fatalf("expected the failed download to be reported, got: %v", err)
+	}
+	expPaths := map[string]string{
+		filepath.Join(dir, "report.pdf"):   "report.pdf",
+		filepath.Join(dir, "report-1.pdf"): "dir/report.pdf",
+	}
+	if len(paths) != len(expPaths) {
+		t.Fatalf("paths expected: %v, got: %v", expPaths, paths)
+	}
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("file should be saved: %v", err)
+		}
+		if string(data) != expPaths[path] {
+			t.Fatalf("content of %s expected: %s, got: %s", path, expPaths[path], data)
+		}
+	}
+}
+
+func Test_AttachmentByName(t *testing.T) {
+	mail := onesecmail.Mail{Attachments: []onesecmail.Attachment{
+		{Filename: "Invoice.PDF", ContentType: "application/pdf", Size: 1024},
+		{Filename: "notes.txt", ContentType: "text/plain", Size: 12},
+	}}
+	tests := []struct {
+		name    string
+		mail    onesecmail.Mail
+		file    string
+		expOK   bool
+		expSize int
+	}{
+		{name: "exact name", mail: mail, file: "notes.txt", expOK: true, expSize: 12},
+		{name: "different case", mail: mail, file: "invoice.pdf", expOK: true, expSize: 1024},
+		{name: "missing", mail: mail, file: "invoice.doc"},
+		{name: "nil attachments", mail: onesecmail.Mail{}, file: "invoice.pdf"},
+		{name: "empty attachments", mail: onesecmail.Mail{Attachments: []onesecmail.Attachment{}}, file: "invoice.pdf"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if has := test.mail.HasAttachments(); has != (len(test.mail.Attachments) > 0) {
+				t.Fatalf("HasAttachments should be %v", !has)
+			}
+			a, ok := test.mail.AttachmentByName(test.file)
+			if ok != test.expOK {
+				t.Fatalf("found expected: %v, got: %v", test.expOK, ok)
+			}
+			if ok && a.Size != test.expSize {
+				t.Fatalf("size expected: %d, got: %d", test.expSize, a.Size)
+			}
+			if !ok && a != nil {
+				t.Fatalf("nil attachment expected, got: %+v", a)
+			}
+		})
+	}
+}
+
+func Test_TotalAttachmentSize(t *testing.T) {
+	tests := []struct {
+		name string
+		mail onesecmail.Mail
+		exp  int
+	}{
+		{name: "no attachments", mail: onesecmail.Mail{}},
+		{name: "one attachment", mail: onesecmail.Mail{Attachments: []onesecmail.Attachment{{Size: 47412}}}, exp: 47412},
+		{
+			name: "several attachments",
+			mail: onesecmail.Mail{Attachments: []onesecmail.Attachment{{Size: 1024}, {Size: 12}, {}}},
+			exp:  1036,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.mail.TotalAttachmentSize(); got != test.exp {
+				t.Fatalf("expected: %d, got: %d", test.exp, got)
+			}
+		})
+	}
+}
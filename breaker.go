@@ -0,0 +1,137 @@
+package onesecmail
+
+import (
+	"errors"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned without making a request while a CircuitBreaker is open.
+var ErrCircuitOpen = errors.New("circuit breaker is open")
+
+// CircuitState is the state of a CircuitBreaker.
+type CircuitState int
+
+const (
+	// CircuitClosed lets requests through.
+	CircuitClosed CircuitState = iota
+	// CircuitOpen rejects requests with ErrCircuitOpen.
+	CircuitOpen
+	// CircuitHalfOpen lets a single probe request through to decide whether to close again.
+	CircuitHalfOpen
+)
+
+func (s CircuitState) String() string {
+	return [...]string{"closed", "open", "half-open"}[s]
+}
+
+// CircuitBreaker stops making requests to the API after consecutive failures, so
+// that an unavailable API is not hammered. A request fails if it returns an error,
+// or a response with a 5xx status code. It is safe for concurrent use.
+type CircuitBreaker struct {
+	threshold    int
+	resetTimeout time.Duration
+
+	mu       sync.Mutex
+	state    CircuitState
+	failures int
+	openedAt time.Time
+	probing  bool
+	// generation changes with the state, so that the outcomes of requests allowed in
+	// an earlier state are ignored.
+	generation uint64
+}
+
+// defaultBreakerThreshold is the number of consecutive failed requests that opens a
+// CircuitBreaker if WithCircuitBreaker is given a threshold that is not positive.
+const defaultBreakerThreshold = 5
+
+// WithCircuitBreaker opens a CircuitBreaker after threshold consecutive failed
+// requests, or 5 if threshold is not positive. After resetTimeout, it lets a probe
+// request through: if it succeeds the breaker closes, otherwise it opens again.
+// Requests that finish after the breaker has changed state do not affect it. The
+// breaker is available through API.CircuitBreaker.
+func WithCircuitBreaker(threshold int, resetTimeout time.Duration) Option {
+	if threshold <= 0 {
+		threshold = defaultBreakerThreshold
+	}
+	return func(cfg *config) {
+		cfg.breaker = &CircuitBreaker{threshold: threshold, resetTimeout: resetTimeout}
+	}
+}
+
+// State returns the current state of the breaker.
+func (b *CircuitBreaker) State() CircuitState {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == CircuitOpen && time.Since(b.openedAt) >= b.resetTimeout {
+		return CircuitHalfOpen
+	}
+	return b.state
+}
+
+// allow reports whether a request may be made, and returns the generation of the
+// breaker to record its outcome with.
+func (b *CircuitBreaker) allow() (uint64, bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.state == CircuitOpen && time.Since(b.openedAt) >= b.resetTimeout {
+		b.setState(CircuitHalfOpen)
+	}
+	switch b.state {
+	case CircuitOpen:
+		return 0, false
+	case CircuitHalfOpen:
+		if b.probing {
+			return 0, false
+		}
+		b.probing = true
+	}
+	return b.generation, true
+}
+
+// record records the outcome of a request allowed in generation. It is ignored if
+// the breaker has changed state since.
+func (b *CircuitBreaker) record(generation uint64, failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if generation != b.generation {
+		return
+	}
+	if !failed {
+		b.failures = 0
+		if b.state != CircuitClosed {
+			b.setState(CircuitClosed)
+		}
+		return
+	}
+	b.failures++
+	if b.state == CircuitHalfOpen || b.failures >= b.threshold {
+		b.setState(CircuitOpen)
+		b.openedAt = time.Now()
+	}
+}
+
+// setState moves the breaker to state, starting a new generation.
+func (b *CircuitBreaker) setState(state CircuitState) {
+	b.state = state
+	b.probing = false
+	b.generation++
+}
+
+// breakerClient is an HTTPClient that makes requests with next through a CircuitBreaker.
+type breakerClient struct {
+	next    HTTPClient
+	breaker *CircuitBreaker
+}
+
+func (c breakerClient) Do(req *http.Request) (*http.Response, error) {
+	generation, ok := c.breaker.allow()
+	if !ok {
+		return nil, ErrCircuitOpen
+	}
+	resp, err := c.next.Do(req)
+	c.breaker.record(generation, err != nil || resp.StatusCode >= 500)
+	return resp, err
+}
@@ -0,0 +1,157 @@
+package onesecmail_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/z11i/onesecmail"
+)
+
+func Test_WithCircuitBreaker(t *testing.T) {
+	var calls int
+	failing := true
+	client := &ClientMock{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			calls++
+			if failing {
+				return statusFailure(503)()
+			}
+			return statusFailure(200)()
+		},
+	}
+	api := onesecmail.NewAPI(onesecmail.WithHTTPClient(client), onesecmail.WithCircuitBreaker(3, 20*time.Millisecond))
+	breaker := api.CircuitBreaker()
+	if breaker.State() != onesecmail.CircuitClosed {
+		t.Fatalf("state expected: closed, got: %v", breaker.State())
+	}
+
+	for i := 0; i < 3; i++ {
+		api.RandomAddresses(context.Background(), 1)
+	}
+	if breaker.State() != onesecmail.CircuitOpen {
+		t.Fatalf("state expected: open, got: %v", breaker.State())
+	}
+	_, err := api.RandomAddresses(context.Background(), 1)
+	if !errors.Is(err, onesecmail.ErrCircuitOpen) {
+		t.Fatalf("expected ErrCircuitOpen, got: %v", err)
+	}
+	if calls != 3 {
+		t.Fatalf("open breaker should not make requests, got %d calls", calls)
+	}
+
+	// A failed probe opens the breaker again.
+	time.Sleep(20 * time.Millisecond)
+	if breaker.State() != onesecmail.CircuitHalfOpen {
+		t.Fatalf("state expected: half-open, got: %v", breaker.State())
+	}
+	api.RandomAddresses(context.Background(), 1)
+	if calls != 4 || breaker.State() != onesecmail.CircuitOpen {
+		t.Fatalf("failed probe should open the breaker, got %d calls and state %v", calls, breaker.State())
+	}
+
+	// A successful probe closes the breaker.
+	time.Sleep(20 * time.Millisecond)
+	failing = false
+	api.RandomAddresses(context.Background(), 1)
+	if calls != 5 || breaker.State() != onesecmail.CircuitClosed {
+		t.Fatalf("successful probe should close the breaker, got %d calls and state %v", calls, breaker.State())
+	}
+}
+
+func Test_CircuitBreaker_NotSet(t *testing.T) {
+	if onesecmail.NewAPI().CircuitBreaker() != nil {
+		t.Fatal("breaker should be nil when not set")
+	}
+}
+
+// blockingClient returns a client whose requests for count 1 fail at once, and whose
+// other requests wait for a status code on the channel returned for their count.
+func blockingClient(started chan<- string) (*ClientMock, map[string]chan int) {
+	release := map[string]chan int{"2": make(chan int), "3": make(chan int)}
+	return &ClientMock{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			count := req.URL.Query().Get("count")
+			if count == "1" {
+				return statusFailure(503)()
+			}
+			started <- count
+			return statusFailure(<-release[count])()
+		},
+	}, release
+}
+
+func Test_CircuitBreaker_LateOutcomes(t *testing.T) {
+	t.Run("late success while open", func(t *testing.T) {
+		started := make(chan string)
+		client, release := blockingClient(started)
+		api := onesecmail.NewAPI(onesecmail.WithHTTPClient(client), onesecmail.WithCircuitBreaker(1, time.Hour))
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			api.RandomAddresses(context.Background(), 2)
+		}()
+		<-started
+		api.RandomAddresses(context.Background(), 1)
+		release["2"] <- 200
+		<-done
+		if state := api.CircuitBreaker().State(); state != onesecmail.CircuitOpen {
+			t.Fatalf("late success should not close the breaker, got: %v", state)
+		}
+	})
+
+	t.Run("late outcome while half-open", func(t *testing.T) {
+		started := make(chan string)
+		client, release := blockingClient(started)
+		api := onesecmail.NewAPI(onesecmail.WithHTTPClient(client), onesecmail.WithCircuitBreaker(1, 20*time.Millisecond))
+		ctx := context.Background()
+		done := make(chan struct{}, 2)
+		go func() {
+			api.RandomAddresses(ctx, 2)
+			done <- struct{}{}
+		}()
+		<-started
+		api.RandomAddresses(ctx, 1)
+		time.Sleep(20 * time.Millisecond)
+		go func() {
+			api.RandomAddresses(ctx, 3)
+			done <- struct{}{}
+		}()
+		if probe := <-started; probe != "3" {
+			t.Fatalf("probe expected, got count: %s", probe)
+		}
+
+		// The late success of a request made while closed leaves the probe running.
+		release["2"] <- 200
+		<-done
+		if _, err := api.RandomAddresses(ctx, 1); !errors.Is(err, onesecmail.ErrCircuitOpen) {
+			t.Fatalf("only one probe expected, got: %v", err)
+		}
+		release["3"] <- 200
+		<-done
+		if state := api.CircuitBreaker().State(); state != onesecmail.CircuitClosed {
+			t.Fatalf("successful probe should close the breaker, got: %v", state)
+		}
+	})
+}
+
+func Test_WithCircuitBreaker_DefaultThreshold(t *testing.T) {
+	var calls int
+	client := &ClientMock{DoFunc: func(req *http.Request) (*http.Response, error) {
+		calls++
+		return statusFailure(503)()
+	}}
+	for _, threshold := range []int{0, -1} {
+		calls = 0
+		api := onesecmail.NewAPI(onesecmail.WithHTTPClient(client), onesecmail.WithCircuitBreaker(threshold, time.Hour))
+		for i := 0; i < 10; i++ {
+			api.RandomAddresses(context.Background(), 1)
+		}
+		if calls != 5 || api.CircuitBreaker().State() != onesecmail.CircuitOpen {
+			t.Fatalf("threshold %d: breaker should open after 5 failures, got %d calls and state %v",
+				threshold, calls, api.CircuitBreaker().State())
+		}
+	}
+}
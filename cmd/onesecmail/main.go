@@ -0,0 +1,165 @@
+// Command onesecmail checks 1secmail inboxes from the shell.
+//
+// Usage:
+//
+//	onesecmail random [-json]
+//	onesecmail inbox [-json] <address>
+//	onesecmail read [-json] <address> <id>
+//
+// random generates a random address, inbox lists the mails in the inbox of an
+// address, and read prints a mail. With -json, results are printed as JSON. Flags
+// may come before or after the arguments, up to a "--" argument.
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/z11i/onesecmail"
+)
+
+// errUsage is returned when the command is used incorrectly.
+var errUsage = errors.New("usage: onesecmail random [-json] | inbox [-json] <address> | read [-json] <address> <id>")
+
+func main() {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	err := run(ctx, os.Args[1:], os.Stdout)
+	stop()
+	switch {
+	case errors.Is(err, errUsage), errors.Is(err, flag.ErrHelp):
+		fmt.Fprintln(os.Stderr, errUsage)
+		os.Exit(2)
+	case err != nil:
+		fmt.Fprintf(os.Stderr, "onesecmail: %v\n", err)
+		os.Exit(1)
+	}
+}
+
+// run runs the command with args, without the program name, and prints its results
+// to stdout. Requests are made with an API configured by opts.
+func run(ctx context.Context, args []string, stdout io.Writer, opts ...onesecmail.Option) error {
+	if len(args) == 0 {
+		return errUsage
+	}
+	fs := flag.NewFlagSet(args[0], flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	asJSON := fs.Bool("json", false, "print results as JSON")
+	args, err := parseArgs(fs, args[1:])
+	if err != nil {
+		return fmt.Errorf("%w: %w", errUsage, err)
+	}
+
+	switch fs.Name() {
+	case "random":
+		if len(args) != 0 {
+			return errUsage
+		}
+		mailbox, err := onesecmail.NewAPI(opts...).RandomMailbox(ctx)
+		if err != nil {
+			return err
+		}
+		if *asJSON {
+			return printJSON(stdout, struct {
+				Address string `json:"address"`
+			}{mailbox.Address()})
+		}
+		_, err = fmt.Fprintln(stdout, mailbox.Address())
+		return err
+	case "inbox":
+		if len(args) != 1 {
+			return errUsage
+		}
+		mailbox, err := onesecmail.NewMailboxWithAddress(args[0], opts...)
+		if err != nil {
+			return err
+		}
+		mails, err := mailbox.CheckInbox(ctx)
+		if err != nil {
+			return err
+		}
+		if *asJSON {
+			return printJSON(stdout, mails)
+		}
+		return printInbox(stdout, mails)
+	case "read":
+		if len(args) != 2 {
+			return errUsage
+		}
+		id, err := strconv.Atoi(args[1])
+		if err != nil {
+			return fmt.Errorf("%w: invalid id: %q", errUsage, args[1])
+		}
+		mailbox, err := onesecmail.NewMailboxWithAddress(args[0], opts...)
+		if err != nil {
+			return err
+		}
+		mail, err := mailbox.ReadMessage(ctx, id)
+		if err != nil {
+			return err
+		}
+		if *asJSON {
+			return printJSON(stdout, mail)
+		}
+		return printMail(stdout, mail)
+	}
+	return errUsage
+}
+
+// parseArgs parses the flags in args with fs, and returns the other arguments. Unlike
+// fs.Parse, it does not stop parsing flags at the first argument that is not a flag,
+// but only at "--".
+func parseArgs(fs *flag.FlagSet, args []string) ([]string, error) {
+	var positional []string
+	for {
+		if err := fs.Parse(args); err != nil {
+			return nil, err
+		}
+		rest := fs.Args()
+		if len(rest) == 0 {
+			return positional, nil
+		}
+		// Parse consumes a "--" it stops at, so every argument after it is positional.
+		if n := len(args) - len(rest); n > 0 && args[n-1] == "--" {
+			return append(positional, rest...), nil
+		}
+		positional = append(positional, rest[0])
+		args = rest[1:]
+	}
+}
+
+func printJSON(w io.Writer, v interface{}) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// printInbox prints mails as a table.
+func printInbox(w io.Writer, mails []*onesecmail.Mail) error {
+	tw := tabwriter.NewWriter(w, 0, 8, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tFROM\tSUBJECT\tDATE")
+	for _, mail := range mails {
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\n", mail.ID, mail.From, mail.Subject, mail.Date)
+	}
+	return tw.Flush()
+}
+
+// printMail prints the headers, attachments and plain text body of mail.
+func printMail(w io.Writer, mail *onesecmail.Mail) error {
+	var b strings.Builder
+	fmt.Fprintf(&b, "From: %s\nSubject: %s\nDate: %s\n", mail.From, mail.Subject, mail.Date)
+	for _, attachment := range mail.Attachments {
+		fmt.Fprintf(&b, "Attachment: %s (%s, %d bytes)\n", attachment.Filename, attachment.ContentType, attachment.Size)
+	}
+	fmt.Fprintf(&b, "\n%s\n", strings.TrimRight(mail.PlainText(), "\n"))
+	_, err := io.WriteString(w, b.String())
+	return err
+}
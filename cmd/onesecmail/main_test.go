@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/z11i/onesecmail"
+	"github.com/z11i/onesecmail/onesecmailtest"
+)
+
+func Test_run(t *testing.T) {
+	text := "Your code is 123456.\n"
+	server := onesecmailtest.NewServer(map[string][]*onesecmail.Mail{
+		"foo@1secmail.com": {
+			{ID: 639, From: "someone@example.com", Subject: "Some subject", Date: "2018-06-08 14:33:55", TextBody: &text,
+				Attachments: []onesecmail.Attachment{{Filename: "iometer.pdf", ContentType: "application/pdf", Size: 47412}}},
+			{ID: 640, From: "other@example.com", Subject: "Other subject", Date: "2018-06-08 14:40:55"},
+		},
+	})
+	defer server.Close()
+
+	tests := []struct {
+		name   string
+		args   []string
+		exp    string
+		expRe  string
+		expErr error
+	}{
+		{name: "random", args: []string{"random"}, expRe: `^[a-z0-9]{10}@1secmail\.com\n$`},
+		{name: "random json", args: []string{"random", "-json"}, expRe: `^\{\n  "address": "[a-z0-9]{10}@1secmail\.com"\n\}\n$`},
+		{
+			name: "inbox",
+			args: []string{"inbox", "foo@1secmail.com"},
+			exp: "ID   FROM                 SUBJECT        DATE\n" +
+				"639  someone@example.com  Some subject   2018-06-08 14:33:55\n" +
+				"640  other@example.com    Other subject  2018-06-08 14:40:55\n",
+		},
+		{
+			name: "read",
+			args: []string{"read", "foo@1secmail.com", "639"},
+			exp: "From: someone@example.com\nSubject: Some subject\nDate: 2018-06-08 14:33:55\n" +
+				"Attachment: iometer.pdf (application/pdf, 47412 bytes)\n\nYour code is 123456.\n",
+		},
+		{name: "no command", args: nil, expErr: errUsage},
+		{name: "unknown command", args: []string{"send"}, expErr: errUsage},
+		{name: "unknown flag", args: []string{"inbox", "-yaml", "foo@1secmail.com"}, expErr: errUsage},
+		{name: "unknown flag after address", args: []string{"inbox", "foo@1secmail.com", "-yaml"}, expErr: errUsage},
+		{name: "flag after --", args: []string{"inbox", "foo@1secmail.com", "--", "-json"}, expErr: errUsage},
+		{name: "address after --", args: []string{"inbox", "--", "-json"}, expErr: onesecmail.ErrInvalidAddress},
+		{name: "missing address", args: []string{"inbox"}, expErr: errUsage},
+		{name: "invalid id", args: []string{"read", "foo@1secmail.com", "abc"}, expErr: errUsage},
+		{name: "invalid address", args: []string{"inbox", "foo@example.com"}, expErr: onesecmail.ErrInvalidDomain},
+		{name: "missing mail", args: []string{"read", "foo@1secmail.com", "1"}, expErr: onesecmail.ErrMessageNotFound},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var out strings.Builder
+			err := run(context.Background(), test.args, &out, onesecmail.WithBaseURL(server.URL))
+			if test.expErr != nil {
+				if !errors.Is(err, test.expErr) {
+					t.Fatalf("expected %v, got: %v", test.expErr, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("should not error: %v", err)
+			}
+			if test.expRe != "" && !regexp.MustCompile(test.expRe).MatchString(out.String()) {
+				t.Fatalf("output expected to match %s, got: %q", test.expRe, out.String())
+			}
+			if test.exp != "" && out.String() != test.exp {
+				t.Fatalf("output expected: %q, got: %q", test.exp, out.String())
+			}
+		})
+	}
+}
+
+func Test_run_JSON(t *testing.T) {
+	server := onesecmailtest.NewServer(map[string][]*onesecmail.Mail{
+		"foo@1secmail.com": {{ID: 639, From: "someone@example.com", Subject: "Some subject", Date: "2018-06-08 14:33:55"}},
+	})
+	defer server.Close()
+
+	// Flags may come before, between or after the arguments.
+	for _, args := range [][]string{
+		{"inbox", "-json", "foo@1secmail.com"},
+		{"inbox", "foo@1secmail.com", "--json"},
+	} {
+		var out strings.Builder
+		if err := run(context.Background(), args, &out, onesecmail.WithBaseURL(server.URL)); err != nil {
+			t.Fatalf("%v: should not error: %v", args, err)
+		}
+		var mails []*onesecmail.Mail
+		if err := json.Unmarshal([]byte(out.String()), &mails); err != nil || len(mails) != 1 || mails[0].ID != 639 {
+			t.Fatalf("%v: 1 mail expected, got: %v, %v", args, mails, err)
+		}
+	}
+
+	for _, args := range [][]string{
+		{"read", "-json", "foo@1secmail.com", "639"},
+		{"read", "foo@1secmail.com", "-json", "639"},
+		{"read", "foo@1secmail.com", "639", "-json"},
+	} {
+		var out strings.Builder
+		if err := run(context.Background(), args, &out, onesecmail.WithBaseURL(server.URL)); err != nil {
+			t.Fatalf("%v: should not error: %v", args, err)
+		}
+		var mail onesecmail.Mail
+		if err := json.Unmarshal([]byte(out.String()), &mail); err != nil || mail.Subject != "Some subject" {
+			t.Fatalf("%v: mail expected, got: %+v, %v", args, mail, err)
+		}
+	}
+}
@@ -0,0 +1,127 @@
+package onesecmail
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// ReadAllMessages reads every mail in the inbox of a mailbox, and returns them
+// ordered by ID. The mails are read concurrently, at most as many at once as set by
+// WithReadConcurrency. If reading some of the mails fails, the others are returned
+// along with the failures joined into an error.
+func (m Mailbox) ReadAllMessages(ctx context.Context) ([]*Mail, error) {
+	summaries, err := m.CheckInbox(ctx)
+	if err != nil {
+		return nil, err
+	}
+	SortByID(summaries, true)
+	return m.readAll(ctx, summaries)
+}
+
+// CheckInboxFull checks the inbox of a mailbox, and reads every mail in it, so that
+// their bodies and attachments are set, e.g. to export the whole inbox. It is like
+// ReadAllMessages, but the mails are returned in the order of the inbox.
+func (m Mailbox) CheckInboxFull(ctx context.Context) ([]*Mail, error) {
+	summaries, err := m.CheckInbox(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return m.readAll(ctx, summaries)
+}
+
+// readAll reads the mails of summaries concurrently, at most as many at once as set
+// by WithReadConcurrency, and returns them in the same order, without those that
+// could not be read, along with the failures joined into an error. Once ctx is done,
+// no more mails are read.
+func (m Mailbox) readAll(ctx context.Context, summaries []*Mail) ([]*Mail, error) {
+	results := make([]*Mail, len(summaries))
+	errs := make([]error, len(summaries))
+	sem := make(chan struct{}, m.cfg.readConcurrency)
+	var (
+		wg      sync.WaitGroup
+		stopErr error
+	)
+	for i, summary := range summaries {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		// A slot taken as ctx is done is not released, as no more reads start.
+		if stopErr = ctx.Err(); stopErr != nil {
+			break
+		}
+		wg.Add(1)
+		go func(i, id int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			mail, err := m.ReadMessage(ctx, id)
+			if err != nil {
+				errs[i] = fmt.Errorf("message %d: %w", id, err)
+				return
+			}
+			results[i] = mail
+		}(i, summary.ID)
+	}
+	wg.Wait()
+
+	mails := make([]*Mail, 0, len(results))
+	for _, mail := range results {
+		if mail != nil {
+			mails = append(mails, mail)
+		}
+	}
+	return mails, errors.Join(append(errs, stopErr)...)
+}
+
+// CheckInboxes checks the inboxes of boxes using a, with at most concurrency inboxes
+// checked at once, and returns their mails keyed by address. If concurrency is not
+// positive, the inboxes are checked one at a time. If checking some of the inboxes
+// fails, the mails of the others are returned along with the failures joined into
+// an error. Once ctx is done, no more inboxes are checked.
+func (a API) CheckInboxes(ctx context.Context, boxes []Mailbox, concurrency int) (map[string][]*Mail, error) {
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+	jobs := make(chan Mailbox)
+	var (
+		mu      sync.Mutex
+		results = make(map[string][]*Mail, len(boxes))
+		errs    []error
+		wg      sync.WaitGroup
+	)
+	for i := 0; i < concurrency && i < len(boxes); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for box := range jobs {
+				mb := Mailbox{Login: box.Login, Domain: box.Domain, API: a}
+				mails, err := mb.CheckInbox(ctx)
+				mu.Lock()
+				if err != nil {
+					errs = append(errs, fmt.Errorf("%s: %w", mb.Address(), err))
+				} else {
+					results[mb.Address()] = mails
+				}
+				mu.Unlock()
+			}
+		}()
+	}
+
+	var err error
+	for _, box := range boxes {
+		if ctx.Err() == nil {
+			select {
+			case jobs <- box:
+				continue
+			case <-ctx.Done():
+			}
+		}
+		err = fmt.Errorf("check inboxes failed: %w", ctx.Err())
+		break
+	}
+	close(jobs)
+	wg.Wait()
+	return results, errors.Join(append(errs, err)...)
+}
@@ -0,0 +1,204 @@
+package onesecmail_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/z11i/onesecmail"
+)
+
+func Test_ReadAllMessages(t *testing.T) {
+	var inFlight, maxInFlight int32
+	client := &ClientMock{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			query := req.URL.Query()
+			body := `[{"id":642},{"id":639},{"id":641},{"id":640},{"id":643}]`
+			if query.Get("action") == "readMessage" {
+				n := atomic.AddInt32(&inFlight, 1)
+				defer atomic.AddInt32(&inFlight, -1)
+				for {
+					max := atomic.LoadInt32(&maxInFlight)
+					if n <= max || atomic.CompareAndSwapInt32(&maxInFlight, max, n) {
+						break
+					}
+				}
+				time.Sleep(10 * time.Millisecond)
+				if query.Get("id") == "640" {
+					return &http.Response{StatusCode: 500, Body: io.NopCloser(strings.NewReader(""))}, nil
+				}
+				body = `{"id":` + query.Get("id") + `,"textBody":"hello"}`
+			}
+			return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(body))}, nil
+		},
+	}
+	mailbox, err := onesecmail.NewMailbox("foo", "1secmail.com",
+		onesecmail.WithHTTPClient(client), onesecmail.WithReadConcurrency(2))
+	if err != nil {
+		t.Fatal("should not error")
+	}
+
+	mails, err := mailbox.ReadAllMessages(context.Background())
+	if !errors.Is(err, onesecmail.ErrHTTPStatus) || !strings.Contains(err.Error(), "message 640") {
+		t.Fatalf("error of message 640 expected, got: %v", err)
+	}
+	ids := mailIDs(mails)
+	if len(ids) != 4 || ids[0] != 639 || ids[1] != 641 || ids[2] != 642 || ids[3] != 643 {
+		t.Fatalf("mails ordered by ID expected, got: %v", ids)
+	}
+	for _, mail := range mails {
+		if mail.TextBody == nil {
+			t.Fatalf("full mail expected, got: %+v", mail)
+		}
+	}
+	if max := atomic.LoadInt32(&maxInFlight); max > 2 {
+		t.Fatalf("at most 2 concurrent reads expected, got: %d", max)
+	}
+}
+
+func Test_CheckInboxFull(t *testing.T) {
+	var inFlight, maxInFlight int32
+	client := &ClientMock{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			query := req.URL.Query()
+			body := `[{"id":642,"subject":"summary"},{"id":639},{"id":641},{"id":640}]`
+			if query.Get("action") == "readMessage" {
+				n := atomic.AddInt32(&inFlight, 1)
+				defer atomic.AddInt32(&inFlight, -1)
+				for {
+					max := atomic.LoadInt32(&maxInFlight)
+					if n <= max || atomic.CompareAndSwapInt32(&maxInFlight, max, n) {
+						break
+					}
+				}
+				time.Sleep(10 * time.Millisecond)
+				if query.Get("id") == "641" {
+					return &http.Response{StatusCode: 404, Body: io.NopCloser(strings.NewReader("Message not found"))}, nil
+				}
+				body = `{"id":` + query.Get("id") + `,"textBody":"hello","attachments":[{"filename":"a.pdf","size":3}]}`
+			}
+			return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(body))}, nil
+		},
+	}
+	mailbox, err := onesecmail.NewMailbox("foo", "1secmail.com",
+		onesecmail.WithHTTPClient(client), onesecmail.WithReadConcurrency(3))
+	if err != nil {
+		t.Fatal("should not error")
+	}
+
+	mails, err := mailbox.CheckInboxFull(context.Background())
+	if !errors.Is(err, onesecmail.ErrMessageNotFound) || !strings.Contains(err.Error(), "message 641") {
+		t.Fatalf("error of message 641 expected, got: %v", err)
+	}
+	if ids := mailIDs(mails); !reflect.DeepEqual(ids, []int{642, 639, 640}) {
+		t.Fatalf("mails in inbox order expected, got: %v", ids)
+	}
+	for _, mail := range mails {
+		if mail.TextBody == nil || len(mail.Attachments) != 1 {
+			t.Fatalf("full mail expected, got: %+v", mail)
+		}
+	}
+	if max := atomic.LoadInt32(&maxInFlight); max > 3 {
+		t.Fatalf("at most 3 concurrent reads expected, got: %d", max)
+	}
+}
+
+func Test_ReadAllMessages_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	inbox := make([]string, 100)
+	for i := range inbox {
+		inbox[i] = fmt.Sprintf(`{"id":%d}`, i+1)
+	}
+	var reads int32
+	client := &ClientMock{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			if req.URL.Query().Get("action") != "readMessage" {
+				return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader("[" + strings.Join(inbox, ",") + "]"))}, nil
+			}
+			atomic.AddInt32(&reads, 1)
+			cancel()
+			<-req.Context().Done()
+			return nil, req.Context().Err()
+		},
+	}
+	mailbox, err := onesecmail.NewMailbox("foo", "1secmail.com",
+		onesecmail.WithHTTPClient(client), onesecmail.WithReadConcurrency(2))
+	if err != nil {
+		t.Fatal("should not error")
+	}
+
+	mails, err := mailbox.ReadAllMessages(ctx)
+	if !errors.Is(err, context.Canceled) || len(mails) != 0 {
+		t.Fatalf("cancellation expected, got: %v, %v", mailIDs(mails), err)
+	}
+	if n := atomic.LoadInt32(&reads); n > 2 {
+		t.Fatalf("at most 2 reads expected once cancelled, got: %d", n)
+	}
+}
+
+func Test_CheckInboxes(t *testing.T) {
+	client := &ClientMock{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			switch req.URL.Query().Get("login") {
+			case "bad":
+				return &http.Response{StatusCode: 500, Body: io.NopCloser(strings.NewReader(""))}, nil
+			case "foo":
+				return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(`[{"id":639},{"id":640}]`))}, nil
+			}
+			return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(`[]`))}, nil
+		},
+	}
+	api := onesecmail.NewAPI(onesecmail.WithHTTPClient(client))
+	var boxes []onesecmail.Mailbox
+	for _, address := range []string{"foo@1secmail.com", "bar@1secmail.org", "bad@1secmail.net"} {
+		mb, err := onesecmail.NewMailboxWithAddress(address)
+		if err != nil {
+			t.Fatalf("should not error: %v", err)
+		}
+		boxes = append(boxes, mb)
+	}
+
+	results, err := api.CheckInboxes(context.Background(), boxes, 2)
+	if !errors.Is(err, onesecmail.ErrHTTPStatus) || !strings.Contains(err.Error(), "bad@1secmail.net") {
+		t.Fatalf("error of bad@1secmail.net expected, got: %v", err)
+	}
+	if len(results) != 2 || len(results["foo@1secmail.com"]) != 2 {
+		t.Fatalf("results of 2 inboxes expected, got: %v", results)
+	}
+	if mails, ok := results["bar@1secmail.org"]; !ok || len(mails) != 0 {
+		t.Fatalf("empty inbox expected, got: %v", mails)
+	}
+}
+
+func Test_CheckInboxes_Cancelled(t *testing.T) {
+	var calls int32
+	client := &ClientMock{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			atomic.AddInt32(&calls, 1)
+			return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(`[]`))}, nil
+		},
+	}
+	api := onesecmail.NewAPI(onesecmail.WithHTTPClient(client))
+	mb, err := onesecmail.NewMailbox("foo", "1secmail.com")
+	if err != nil {
+		t.Fatalf("should not error: %v", err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	results, err := api.CheckInboxes(ctx, []onesecmail.Mailbox{mb, mb, mb, mb}, 1)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got: %v", err)
+	}
+	if len(results) != 0 || atomic.LoadInt32(&calls) != 0 {
+		t.Fatalf("no inbox should be checked, got: %v after %d requests", results, calls)
+	}
+}
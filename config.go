@@ -0,0 +1,53 @@
+package onesecmail
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// fileConfig is the content of a config file read by NewMailboxFromConfig.
+type fileConfig struct {
+	Login   string `json:"login" yaml:"login"`
+	Domain  string `json:"domain" yaml:"domain"`
+	BaseURL string `json:"base_url" yaml:"base_url"`
+}
+
+// NewMailboxFromConfig returns a new Mailbox read from the config file at path. A
+// file with the .yaml or .yml extension is read as YAML, and any other file as JSON,
+// e.g.
+//
+//	{"login": "foo", "domain": "1secmail.com", "base_url": "http://127.0.0.1:8080/"}
+//
+// The login and domain are required. The optional base_url is set as by WithBaseURL.
+// The Mailbox is configured by opts, as in NewAPI, which are applied after the
+// base_url of the file.
+func NewMailboxFromConfig(path string, opts ...Option) (Mailbox, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return Mailbox{}, fmt.Errorf("mailbox from config failed: %w", err)
+	}
+	var cfg fileConfig
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		err = yaml.Unmarshal(data, &cfg)
+	default:
+		err = json.NewDecoder(bytes.NewReader(data)).Decode(&cfg)
+	}
+	if err != nil {
+		return Mailbox{}, fmt.Errorf("mailbox from config failed: parse %s: %w", path, err)
+	}
+	if cfg.Login == "" || cfg.Domain == "" {
+		return Mailbox{}, fmt.Errorf("mailbox from config failed: %s: login and domain are required", path)
+	}
+	mailbox, err := NewMailbox(cfg.Login, cfg.Domain, append([]Option{WithBaseURL(cfg.BaseURL)}, opts...)...)
+	if err != nil {
+		return Mailbox{}, fmt.Errorf("mailbox from config failed: %s: %w", path, err)
+	}
+	return mailbox, nil
+}
@@ -0,0 +1,104 @@
+package onesecmail_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/z11i/onesecmail"
+)
+
+func Test_NewMailboxFromConfig(t *testing.T) {
+	tests := []struct {
+		name       string
+		file       string
+		content    string
+		expAddress string
+		expURL     string
+		expErr     string
+		expIs      error
+	}{
+		{
+			name:       "json",
+			file:       "mailbox.json",
+			content:    `{"login":"foo","domain":"1secmail.com"}`,
+			expAddress: "foo@1secmail.com",
+			expURL:     "https://www.1secmail.com/api/v1/",
+		},
+		{
+			name:       "json with base URL",
+			file:       "mailbox.json",
+			content:    `{"login":"foo","domain":"1secmail.com","base_url":"http://127.0.0.1:8080/api/"}`,
+			expAddress: "foo@1secmail.com",
+			expURL:     "http://127.0.0.1:8080/api/",
+		},
+		{
+			name:       "yaml",
+			file:       "mailbox.yaml",
+			content:    "login: bar\ndomain: 1secmail.org\nbase_url: http://127.0.0.1:8080/api/\n",
+			expAddress: "bar@1secmail.org",
+			expURL:     "http://127.0.0.1:8080/api/",
+		},
+		{
+			name:       "yml",
+			file:       "mailbox.YML",
+			content:    "login: bar\ndomain: 1secmail.org\n",
+			expAddress: "bar@1secmail.org",
+			expURL:     "https://www.1secmail.com/api/v1/",
+		},
+		{name: "missing file", file: "", expErr: "mailbox from config failed", expIs: os.ErrNotExist},
+		{name: "malformed json", file: "mailbox.json", content: `{"login":`, expErr: "parse"},
+		{name: "malformed yaml", file: "mailbox.yaml", content: "login: [", expErr: "parse"},
+		{name: "missing domain", file: "mailbox.json", content: `{"login":"foo"}`, expErr: "login and domain are required"},
+		{
+			name:    "invalid domain",
+			file:    "mailbox.json",
+			content: `{"login":"foo","domain":"example.com"}`,
+			expErr:  "mailbox from config failed",
+			expIs:   onesecmail.ErrInvalidDomain,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "missing.json")
+			if test.file != "" {
+				path = filepath.Join(t.TempDir(), test.file)
+				if err := os.WriteFile(path, []byte(test.content), 0600); err != nil {
+					t.Fatal(err)
+				}
+			}
+			var gotURL string
+			client := &ClientMock{DoFunc: func(req *http.Request) (*http.Response, error) {
+				gotURL = req.URL.Scheme + "://" + req.URL.Host + req.URL.Path
+				return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(`[]`))}, nil
+			}}
+			mailbox, err := onesecmail.NewMailboxFromConfig(path, onesecmail.WithHTTPClient(client))
+			if test.expErr != "" {
+				if err == nil || !strings.Contains(err.Error(), test.expErr) {
+					t.Fatalf("error expected: %s, got: %v", test.expErr, err)
+				}
+				if test.expIs != nil && !errors.Is(err, test.expIs) {
+					t.Fatalf("error expected to wrap: %v, got: %v", test.expIs, err)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("should not error: %v", err)
+			}
+			if mailbox.Address() != test.expAddress {
+				t.Fatalf("address expected: %s, got: %s", test.expAddress, mailbox.Address())
+			}
+			if _, err := mailbox.CheckInbox(context.Background()); err != nil {
+				t.Fatalf("should not error: %v", err)
+			}
+			if gotURL != test.expURL {
+				t.Fatalf("URL expected: %s, got: %s", test.expURL, gotURL)
+			}
+		})
+	}
+}
@@ -0,0 +1,58 @@
+package onesecmail
+
+import (
+	"encoding/csv"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// csvFields maps the fields exported by ExportToCSV to their values.
+var csvFields = map[string]func(m *Mail) string{
+	"id":          func(m *Mail) string { return strconv.Itoa(m.ID) },
+	"from":        func(m *Mail) string { return m.From },
+	"subject":     func(m *Mail) string { return m.Subject },
+	"date":        func(m *Mail) string { return m.Date },
+	"attachments": func(m *Mail) string { return strconv.Itoa(len(m.Attachments)) },
+	"size":        func(m *Mail) string { return strconv.Itoa(m.TotalAttachmentSize()) },
+}
+
+// defaultCSVFields is the order of the fields exported by ExportToCSV by default.
+var defaultCSVFields = []string{"id", "from", "subject", "date", "attachments", "size"}
+
+// ExportToCSV writes the metadata of mails to w as CSV, with a header row of the
+// field names. The fields are a subset of "id", "from", "subject", "date",
+// "attachments", which is the number of attachments, and "size", which is the total
+// size of the attachments. If fields is empty, all of them are exported.
+func ExportToCSV(mails []*Mail, w io.Writer, fields []string) error {
+	if len(fields) == 0 {
+		fields = defaultCSVFields
+	}
+	values := make([]func(m *Mail) string, len(fields))
+	for i, field := range fields {
+		value, ok := csvFields[field]
+		if !ok {
+			return fmt.Errorf("export CSV failed: unknown field: %q", field)
+		}
+		values[i] = value
+	}
+
+	cw := csv.NewWriter(w)
+	if err := cw.Write(fields); err != nil {
+		return fmt.Errorf("export CSV failed: %w", err)
+	}
+	record := make([]string, len(fields))
+	for _, m := range mails {
+		for i, value := range values {
+			record[i] = value(m)
+		}
+		if err := cw.Write(record); err != nil {
+			return fmt.Errorf("export CSV failed: %w", err)
+		}
+	}
+	cw.Flush()
+	if err := cw.Error(); err != nil {
+		return fmt.Errorf("export CSV failed: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,54 @@
+package onesecmail_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/z11i/onesecmail"
+)
+
+func Test_ExportToCSV(t *testing.T) {
+	mails := []*onesecmail.Mail{
+		{ID: 639, From: "someone@example.com", Subject: "Hello, world", Date: "2018-06-08 14:33:55",
+			Attachments: []onesecmail.Attachment{{Filename: "a.pdf", Size: 100}, {Filename: "b.pdf", Size: 23}}},
+		{ID: 640, From: "someoneelse@example.com", Subject: `Say "hi"`, Date: "2018-06-08 14:40:55"},
+	}
+	tests := []struct {
+		name   string
+		fields []string
+		exp    string
+		expErr bool
+	}{
+		{
+			name: "all fields",
+			exp: "id,from,subject,date,attachments,size\n" +
+				"639,someone@example.com,\"Hello, world\",2018-06-08 14:33:55,2,123\n" +
+				"640,someoneelse@example.com,\"Say \"\"hi\"\"\",2018-06-08 14:40:55,0,0\n",
+		},
+		{
+			name:   "subset of fields",
+			fields: []string{"subject", "id"},
+			exp:    "subject,id\n\"Hello, world\",639\n\"Say \"\"hi\"\"\",640\n",
+		},
+		{name: "unknown field", fields: []string{"id", "body"}, expErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := onesecmail.ExportToCSV(mails, &buf, test.fields)
+			if (err == nil) != !test.expErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err != nil {
+				if !strings.Contains(err.Error(), `"body"`) || buf.Len() != 0 {
+					t.Fatalf("unknown field should be reported before writing, got: %v", err)
+				}
+				return
+			}
+			if got := buf.String(); got != test.exp {
+				t.Fatalf("CSV expected:\n%s\ngot:\n%s", test.exp, got)
+			}
+		})
+	}
+}
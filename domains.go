@@ -1,7 +1,13 @@
 package onesecmail
 
-import "sync"
+import (
+	"sync"
+	"time"
+)
 
+// Domains is the list of domains that 1secmail supports. It is replaced by
+// API.RefreshDomains. Use IsDomain, AddDomain and RemoveDomain to access it
+// safely from multiple goroutines.
 var Domains = map[string]struct{}{
 	"1secmail.com": {},
 	"1secmail.org": {},
@@ -13,4 +19,66 @@ var Domains = map[string]struct{}{
 	"wuuvo.com":    {},
 }
 
-var domainsMu sync.Mutex
+var domainsMu sync.RWMutex
+
+// IsDomain reports whether domain is one that 1secmail supports.
+func IsDomain(domain string) bool {
+	domainsMu.RLock()
+	defer domainsMu.RUnlock()
+	_, ok := Domains[domain]
+	return ok
+}
+
+// AddDomain adds domain to the list of domains that 1secmail supports.
+func AddDomain(domain string) {
+	domainsMu.Lock()
+	defer domainsMu.Unlock()
+	Domains[domain] = struct{}{}
+}
+
+// RemoveDomain removes domain from the list of domains that 1secmail supports.
+func RemoveDomain(domain string) {
+	domainsMu.Lock()
+	defer domainsMu.Unlock()
+	delete(Domains, domain)
+}
+
+// domainCache caches the live list of domains returned by API.Domains.
+type domainCache struct {
+	ttl time.Duration
+
+	mu      sync.Mutex
+	domains []string
+	expires time.Time
+}
+
+// get returns a copy of the cached domains, and whether they have not expired.
+func (c *domainCache) get() ([]string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.domains == nil || !time.Now().Before(c.expires) {
+		return nil, false
+	}
+	return append([]string(nil), c.domains...), true
+}
+
+// set caches a copy of domains for the TTL of c.
+func (c *domainCache) set(domains []string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.domains = append([]string{}, domains...)
+	c.expires = time.Now().Add(c.ttl)
+}
+
+// WithDomainCacheTTL caches the list of domains returned by API.Domains for d, so
+// that it is only requested again once d has passed. Copies of an API or a Mailbox
+// share its cache, except those made by Mailbox.Clone and Mailbox.WithClient. If it
+// is not set, or d is zero or negative, the list is not cached.
+func WithDomainCacheTTL(d time.Duration) Option {
+	return func(cfg *config) {
+		cfg.domainCache = nil
+		if d > 0 {
+			cfg.domainCache = &domainCache{ttl: d}
+		}
+	}
+}
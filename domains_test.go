@@ -0,0 +1,128 @@
+package onesecmail_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/z11i/onesecmail"
+)
+
+func Test_IsDomain(t *testing.T) {
+	if !onesecmail.IsDomain("1secmail.com") {
+		t.Fatal("1secmail.com should be a domain")
+	}
+	if onesecmail.IsDomain("example.com") {
+		t.Fatal("example.com should not be a domain")
+	}
+
+	onesecmail.AddDomain("example.com")
+	if !onesecmail.IsDomain("example.com") {
+		t.Fatal("added domain should be a domain")
+	}
+	if _, err := onesecmail.NewMailbox("foo", "example.com"); err != nil {
+		t.Fatalf("added domain should be valid: %v", err)
+	}
+
+	onesecmail.RemoveDomain("example.com")
+	if onesecmail.IsDomain("example.com") {
+		t.Fatal("removed domain should not be a domain")
+	}
+}
+
+func Test_Domains_Concurrent(t *testing.T) {
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(3)
+		go func() {
+			defer wg.Done()
+			onesecmail.AddDomain("example.net")
+		}()
+		go func() {
+			defer wg.Done()
+			onesecmail.RemoveDomain("example.net")
+		}()
+		go func() {
+			defer wg.Done()
+			onesecmail.NewMailboxWithAddress("foo@1secmail.com")
+		}()
+	}
+	wg.Wait()
+	onesecmail.RemoveDomain("example.net")
+}
+
+func Test_WithDomainCacheTTL(t *testing.T) {
+	var calls int32
+	client := &ClientMock{DoFunc: func(req *http.Request) (*http.Response, error) {
+		atomic.AddInt32(&calls, 1)
+		return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(`["1secmail.com","1secmail.org"]`))}, nil
+	}}
+	ctx := context.Background()
+
+	t.Run("cached until expiry", func(t *testing.T) {
+		atomic.StoreInt32(&calls, 0)
+		api := onesecmail.NewAPI(onesecmail.WithHTTPClient(client), onesecmail.WithDomainCacheTTL(50*time.Millisecond))
+		var wg sync.WaitGroup
+		for i := 0; i < 10; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if _, err := api.Domains(ctx); err != nil {
+					t.Errorf("should not error: %v", err)
+				}
+			}()
+		}
+		wg.Wait()
+		first := atomic.LoadInt32(&calls)
+		if first < 1 {
+			t.Fatal("domains should be requested")
+		}
+		domains, err := api.Domains(ctx)
+		if err != nil || !reflect.DeepEqual(domains, []string{"1secmail.com", "1secmail.org"}) {
+			t.Fatalf("cached domains expected, got: %v, %v", domains, err)
+		}
+		domains[0] = "changed"
+		if domains, _ := api.Domains(ctx); domains[0] != "1secmail.com" {
+			t.Fatal("cache should not be changed through a returned slice")
+		}
+		if got := atomic.LoadInt32(&calls); got != first {
+			t.Fatalf("no more requests expected, got: %d", got-first)
+		}
+
+		time.Sleep(60 * time.Millisecond)
+		if _, err := api.Domains(ctx); err != nil {
+			t.Fatalf("should not error: %v", err)
+		}
+		if got := atomic.LoadInt32(&calls); got != first+1 {
+			t.Fatalf("1 more request expected after expiry, got: %d", got-first)
+		}
+	})
+	t.Run("not cached", func(t *testing.T) {
+		for _, opt := range []onesecmail.Option{nil, onesecmail.WithDomainCacheTTL(0)} {
+			atomic.StoreInt32(&calls, 0)
+			api := onesecmail.NewAPI(onesecmail.WithHTTPClient(client), opt)
+			api.Domains(ctx)
+			api.Domains(ctx)
+			if got := atomic.LoadInt32(&calls); got != 2 {
+				t.Fatalf("2 requests expected, got: %d", got)
+			}
+		}
+	})
+	t.Run("health check bypasses cache", func(t *testing.T) {
+		atomic.StoreInt32(&calls, 0)
+		api := onesecmail.NewAPI(onesecmail.WithHTTPClient(client), onesecmail.WithDomainCacheTTL(time.Hour))
+		api.Domains(ctx)
+		if err := api.HealthCheck(ctx); err != nil {
+			t.Fatalf("should not error: %v", err)
+		}
+		if got := atomic.LoadInt32(&calls); got != 2 {
+			t.Fatalf("2 requests expected, got: %d", got)
+		}
+	})
+}
@@ -0,0 +1,215 @@
+package onesecmail
+
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"net/textproto"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// ToEML returns the mail as an RFC 2822 message, as written by WriteEML without
+// a recipient.
+func (m *Mail) ToEML() ([]byte, error) {
+	var buf bytes.Buffer
+	if err := m.WriteEML(&buf, ""); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+// WriteEML writes the mail to w as an RFC 2822 message, which can be opened by email
+// clients. If to is not empty, such as the Address of a Mailbox, it is written as
+// the To header. A mail with both a TextBody and an HTMLBody is written as
+// multipart/alternative, and a mail with Attachments as multipart/mixed. The
+// attachments are written with their Content, if any, and their metadata, including
+// their size. The From and to addresses are encoded if they are not ASCII, and an
+// error wrapping ErrInvalidAddress is returned if either contains a line break.
+func (m *Mail) WriteEML(w io.Writer, to string) error {
+	from, err := headerAddress(m.From)
+	if err != nil {
+		return fmt.Errorf("write EML failed: From: %w", err)
+	}
+	if to, err = headerAddress(to); err != nil {
+		return fmt.Errorf("write EML failed: To: %w", err)
+	}
+	var buf bytes.Buffer
+	fmt.Fprintf(&buf, "From: %s\r\n", from)
+	if to != "" {
+		fmt.Fprintf(&buf, "To: %s\r\n", to)
+	}
+	fmt.Fprintf(&buf, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", m.Subject))
+	if date, err := m.ParseDate(); err == nil {
+		fmt.Fprintf(&buf, "Date: %s\r\n", date.Format(time.RFC1123Z))
+	}
+	buf.WriteString("MIME-Version: 1.0\r\n")
+
+	body := m.emlEntity()
+	writeHeader(&buf, body.header)
+	buf.WriteString("\r\n")
+	if err := body.write(&buf); err != nil {
+		return fmt.Errorf("write EML failed: %w", err)
+	}
+	if _, err := w.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("write EML failed: %w", err)
+	}
+	return nil
+}
+
+// headerAddress returns address formatted for an address header. An ASCII address
+// is returned as it is. Otherwise an address with a display name, such as
+// "Zoë <user@example.com>", is formatted as by mail.Address.String, which encodes
+// the name, and any other address is Q-encoded.
+func headerAddress(address string) (string, error) {
+	if strings.ContainsAny(address, "\r\n") {
+		return "", fmt.Errorf("%w: %q", ErrInvalidAddress, address)
+	}
+	if !strings.ContainsFunc(address, func(r rune) bool { return r > unicode.MaxASCII }) {
+		return address, nil
+	}
+	if addr, err := mail.ParseAddress(address); err == nil && addr.Name != "" {
+		return addr.String(), nil
+	}
+	return mime.QEncoding.Encode("utf-8", address), nil
+}
+
+// entity is a MIME entity: its header, and a function writing its encoded body.
+type entity struct {
+	header textproto.MIMEHeader
+	write  func(w io.Writer) error
+}
+
+// emlEntity returns the MIME entity of the body and attachments of the mail.
+func (m *Mail) emlEntity() entity {
+	var text, html string
+	if m.TextBody != nil {
+		text = *m.TextBody
+	}
+	if m.HTMLBody != nil {
+		html = *m.HTMLBody
+	}
+	if text == "" && html == "" && m.Body != nil {
+		html = *m.Body
+	}
+
+	var body entity
+	switch {
+	case text != "" && html != "":
+		body = multipartEntity("alternative", textEntity("text/plain", text), textEntity("text/html", html))
+	case html != "":
+		body = textEntity("text/html", html)
+	default:
+		body = textEntity("text/plain", text)
+	}
+	if len(m.Attachments) == 0 {
+		return body
+	}
+	parts := []entity{body}
+	for _, attachment := range m.Attachments {
+		parts = append(parts, attachmentEntity(attachment))
+	}
+	return multipartEntity("mixed", parts...)
+}
+
+func textEntity(contentType, s string) entity {
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Type", contentType+"; charset=utf-8")
+	header.Set("Content-Transfer-Encoding", "quoted-printable")
+	return entity{header: header, write: func(w io.Writer) error {
+		qw := quotedprintable.NewWriter(w)
+		if _, err := io.WriteString(qw, s); err != nil {
+			return err
+		}
+		if err := qw.Close(); err != nil {
+			return err
+		}
+		if strings.HasSuffix(s, "\n") {
+			return nil
+		}
+		// End the entity with a soft line break, which keeps the content unchanged.
+		_, err := io.WriteString(w, "=\r\n")
+		return err
+	}}
+}
+
+// attachmentEntity returns the MIME entity of an attachment. Its ContentType is
+// parsed and formatted again, so that it cannot add headers, and is written as
+// application/octet-stream if it is not a valid media type.
+func attachmentEntity(a Attachment) entity {
+	contentType := "application/octet-stream"
+	if mediaType, params, err := mime.ParseMediaType(a.ContentType); err == nil {
+		if formatted := mime.FormatMediaType(mediaType, params); formatted != "" {
+			contentType = formatted
+		}
+	}
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Type", contentType)
+	params := map[string]string{"filename": a.Filename}
+	if a.Size > 0 {
+		params["size"] = strconv.Itoa(a.Size)
+	}
+	header.Set("Content-Disposition", mime.FormatMediaType("attachment", params))
+	header.Set("Content-Transfer-Encoding", "base64")
+	return entity{header: header, write: func(w io.Writer) error {
+		if a.Content == nil {
+			return nil
+		}
+		encoded := base64.StdEncoding.EncodeToString(a.Content)
+		for len(encoded) > 0 {
+			n := min(len(encoded), base64LineLength)
+			if _, err := io.WriteString(w, encoded[:n]+"\r\n"); err != nil {
+				return err
+			}
+			encoded = encoded[n:]
+		}
+		return nil
+	}}
+}
+
+// base64LineLength is the maximum length of a line of base64 content in a MIME entity.
+const base64LineLength = 76
+
+func multipartEntity(subtype string, parts ...entity) entity {
+	boundary := multipart.NewWriter(io.Discard).Boundary()
+	header := make(textproto.MIMEHeader)
+	header.Set("Content-Type", mime.FormatMediaType("multipart/"+subtype, map[string]string{"boundary": boundary}))
+	return entity{header: header, write: func(w io.Writer) error {
+		mw := multipart.NewWriter(w)
+		if err := mw.SetBoundary(boundary); err != nil {
+			return err
+		}
+		for _, part := range parts {
+			pw, err := mw.CreatePart(part.header)
+			if err != nil {
+				return err
+			}
+			if err := part.write(pw); err != nil {
+				return err
+			}
+		}
+		return mw.Close()
+	}}
+}
+
+// writeHeader writes header to buf, sorted by key.
+func writeHeader(buf *bytes.Buffer, header textproto.MIMEHeader) {
+	keys := make([]string, 0, len(header))
+	for k := range header {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		for _, v := range header[k] {
+			fmt.Fprintf(buf, "%s: %s\r\n", k, v)
+		}
+	}
+}
@@ -0,0 +1,268 @@
+package onesecmail_test
+
+import (
+	"bytes"
+	"encoding/base64"
+	"errors"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+	"unicode"
+
+	"github.com/z11i/onesecmail"
+)
+
+// mimePart is a leaf MIME part of a parsed message.
+type mimePart struct {
+	contentType string
+	filename    string
+	content     string
+}
+
+// mimeParts returns the leaf parts of a MIME entity, flattening multiparts.
+func mimeParts(t *testing.T, contentType, transferEncoding string, body io.Reader) []mimePart {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		t.Fatalf("parse media type failed: %v", err)
+	}
+	if !strings.HasPrefix(mediaType, "multipart/") {
+		if transferEncoding == "quoted-printable" {
+			body = quotedprintable.NewReader(body)
+		}
+		content, err := io.ReadAll(body)
+		if err != nil {
+			t.Fatalf("read part failed: %v", err)
+		}
+		return []mimePart{{contentType: mediaType, content: string(content)}}
+	}
+	var parts []mimePart
+	mr := multipart.NewReader(body, params["boundary"])
+	for {
+		p, err := mr.NextPart()
+		if err == io.EOF {
+			return parts
+		}
+		if err != nil {
+			t.Fatalf("read part failed: %v", err)
+		}
+		if p.FileName() != "" {
+			content, err := io.ReadAll(base64.NewDecoder(base64.StdEncoding, p))
+			if err != nil {
+				t.Fatalf("read attachment failed: %v", err)
+			}
+			parts = append(parts, mimePart{contentType: p.Header.Get("Content-Type"), filename: p.FileName(), content: string(content)})
+			continue
+		}
+		parts = append(parts, mimeParts(t, p.Header.Get("Content-Type"), p.Header.Get("Content-Transfer-Encoding"), p)...)
+	}
+}
+
+func Test_ToEML(t *testing.T) {
+	text, html := "Your code is 123456.\nFrom the team", "<p>Your code is <b>123456</b>.</p>"
+	tests := []struct {
+		name     string
+		mail     onesecmail.Mail
+		expType  string
+		expParts []mimePart
+	}{
+		{
+			name:     "text body",
+			mail:     onesecmail.Mail{TextBody: &text},
+			expType:  "text/plain",
+			expParts: []mimePart{{contentType: "text/plain", content: "Your code is 123456.\r\nFrom the team"}},
+		},
+		{
+			name:     "html body",
+			mail:     onesecmail.Mail{HTMLBody: &html},
+			expType:  "text/html",
+			expParts: []mimePart{{contentType: "text/html", content: html}},
+		},
+		{
+			name:     "body only",
+			mail:     onesecmail.Mail{Body: &html},
+			expType:  "text/html",
+			expParts: []mimePart{{contentType: "text/html", content: html}},
+		},
+		{
+			name:    "text and html bodies",
+			mail:    onesecmail.Mail{TextBody: &text, HTMLBody: &html},
+			expType: "multipart/alternative",
+			expParts: []mimePart{
+				{contentType: "text/plain", content: "Your code is 123456.\r\nFrom the team"},
+				{contentType: "text/html", content: html},
+			},
+		},
+		{
+			name: "attachments",
+			mail: onesecmail.Mail{TextBody: &text, HTMLBody: &html, Attachments: []onesecmail.Attachment{
+				{Filename: "iometer.pdf", ContentType: "application/pdf", Size: 47412},
+				{Filename: "notes.txt"},
+			}},
+			expType: "multipart/mixed",
+			expParts: []mimePart{
+				{contentType: "text/plain", content: "Your code is 123456.\r\nFrom the team"},
+				{contentType: "text/html", content: html},
+				{contentType: "application/pdf", filename: "iometer.pdf"},
+				{contentType: "application/octet-stream", filename: "notes.txt"},
+			},
+		},
+		{
+			name: "attachment content",
+			mail: onesecmail.Mail{TextBody: &text, Attachments: []onesecmail.Attachment{
+				{Filename: "notes.txt", ContentType: "text/plain", Content: []byte(strings.Repeat("note ", 40))},
+				{Filename: "empty.txt", ContentType: "text/plain", Content: []byte{}},
+			}},
+			expType: "multipart/mixed",
+			expParts: []mimePart{
+				{contentType: "text/plain", content: "Your code is 123456.\r\nFrom the team"},
+				{contentType: "text/plain", filename: "notes.txt", content: strings.Repeat("note ", 40)},
+				{contentType: "text/plain", filename: "empty.txt"},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.mail.From = "someone@example.com"
+			test.mail.Subject = "Vérification"
+			test.mail.Date = "2018-06-08 14:33:55"
+			eml, err := test.mail.ToEML()
+			if err != nil {
+				t.Fatalf("should not error: %v", err)
+			}
+			msg, err := mail.ReadMessage(bytes.NewReader(eml))
+			if err != nil {
+				t.Fatalf("parse EML failed: %v", err)
+			}
+			if from := msg.Header.Get("From"); from != "someone@example.com" {
+				t.Fatalf("from expected: someone@example.com, got: %s", from)
+			}
+			if to := msg.Header.Get("To"); to != "" {
+				t.Fatalf("to should be empty, got: %s", to)
+			}
+			subject, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("Subject"))
+			if err != nil || subject != "Vérification" {
+				t.Fatalf("subject expected: Vérification, got: %s, %v", subject, err)
+			}
+			date, err := msg.Header.Date()
+			if err != nil || !date.Equal(time.Date(2018, 6, 8, 14, 33, 55, 0, time.UTC)) {
+				t.Fatalf("unexpected date: %v, %v", date, err)
+			}
+			contentType := msg.Header.Get("Content-Type")
+			if mediaType, _, _ := mime.ParseMediaType(contentType); mediaType != test.expType {
+				t.Fatalf("content type expected: %s, got: %s", test.expType, contentType)
+			}
+			if parts := mimeParts(t, contentType, msg.Header.Get("Content-Transfer-Encoding"), msg.Body); !reflect.DeepEqual(parts, test.expParts) {
+				t.Fatalf("parts expected: %+v, got: %+v", test.expParts, parts)
+			}
+		})
+	}
+}
+
+func Test_WriteEML_To(t *testing.T) {
+	var buf bytes.Buffer
+	m := onesecmail.Mail{From: "someone@example.com", Subject: "Hi", Date: "not a date"}
+	if err := m.WriteEML(&buf, "foo@1secmail.com"); err != nil {
+		t.Fatalf("should not error: %v", err)
+	}
+	msg, err := mail.ReadMessage(&buf)
+	if err != nil {
+		t.Fatalf("parse EML failed: %v", err)
+	}
+	if to := msg.Header.Get("To"); to != "foo@1secmail.com" {
+		t.Fatalf("to expected: foo@1secmail.com, got: %s", to)
+	}
+	if date := msg.Header.Get("Date"); date != "" {
+		t.Fatalf("invalid date should be omitted, got: %s", date)
+	}
+}
+
+func Test_WriteEML_Addresses(t *testing.T) {
+	tests := []struct {
+		name    string
+		from    string
+		to      string
+		expFrom string
+		expErr  bool
+	}{
+		{name: "plain address", from: "someone@example.com", expFrom: "someone@example.com"},
+		{name: "display name", from: "Sender <someone@example.com>", expFrom: "Sender <someone@example.com>"},
+		{name: "non-ASCII display name", from: "Zoë <someone@example.com>", expFrom: "Zoë <someone@example.com>"},
+		{name: "not an address", from: "Zoë", expFrom: "Zoë"},
+		{name: "line break in from", from: "a@example.com\r\nBcc: victim@example.com", expErr: true},
+		{name: "line break in to", from: "a@example.com", to: "foo@1secmail.com\nBcc: victim@example.com", expErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			m := onesecmail.Mail{From: test.from, Subject: "Hi"}
+			err := m.WriteEML(&buf, test.to)
+			if test.expErr {
+				if !errors.Is(err, onesecmail.ErrInvalidAddress) || buf.Len() != 0 {
+					t.Fatalf("ErrInvalidAddress and no output expected, got: %v, %q", err, buf.String())
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("should not error: %v", err)
+			}
+			raw := buf.String()
+			msg, err := mail.ReadMessage(&buf)
+			if err != nil {
+				t.Fatalf("parse EML failed: %v", err)
+			}
+			header := raw[:strings.Index(raw, "\r\n\r\n")]
+			if strings.IndexFunc(header, func(r rune) bool { return r > unicode.MaxASCII }) >= 0 {
+				t.Fatalf("non-ASCII header should be encoded, got: %q", header)
+			}
+			from, err := new(mime.WordDecoder).DecodeHeader(msg.Header.Get("From"))
+			if err != nil || from != test.expFrom {
+				t.Fatalf("from expected: %s, got: %s, %v", test.expFrom, from, err)
+			}
+			if bcc := msg.Header.Get("Bcc"); bcc != "" {
+				t.Fatalf("no Bcc expected, got: %s", bcc)
+			}
+		})
+	}
+}
+
+func Test_WriteEML_AttachmentContentType(t *testing.T) {
+	tests := []struct {
+		name        string
+		contentType string
+		exp         string
+	}{
+		{name: "media type", contentType: "application/pdf", exp: "application/pdf"},
+		{name: "parameters", contentType: "Text/Plain; charset=UTF-8", exp: "text/plain; charset=UTF-8"},
+		{name: "empty", contentType: "", exp: "application/octet-stream"},
+		{name: "invalid", contentType: "not a media type", exp: "application/octet-stream"},
+		{name: "line break", contentType: "text/plain\r\nBcc: victim@example.com", exp: "application/octet-stream"},
+		{name: "line break in parameter", contentType: "text/plain; name=\"a\r\nBcc: victim@example.com\"", exp: "application/octet-stream"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			m := onesecmail.Mail{From: "someone@example.com", TextBody: strPtr("hi"),
+				Attachments: []onesecmail.Attachment{{Filename: "a.txt", ContentType: test.contentType, Content: []byte("a")}}}
+			eml, err := m.ToEML()
+			if err != nil {
+				t.Fatalf("should not error: %v", err)
+			}
+			if strings.Contains(string(eml), "\r\nBcc:") {
+				t.Fatalf("no Bcc header expected, got: %q", eml)
+			}
+			msg, err := mail.ReadMessage(bytes.NewReader(eml))
+			if err != nil {
+				t.Fatalf("parse EML failed: %v", err)
+			}
+			parts := mimeParts(t, msg.Header.Get("Content-Type"), msg.Header.Get("Content-Transfer-Encoding"), msg.Body)
+			if len(parts) != 2 || parts[1].contentType != test.exp || parts[1].content != "a" {
+				t.Fatalf("attachment of type %s expected, got: %+v", test.exp, parts)
+			}
+		})
+	}
+}
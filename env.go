@@ -0,0 +1,36 @@
+package onesecmail
+
+import (
+	"fmt"
+	"os"
+)
+
+// Environment variables read by NewMailboxFromEnv, and by NewAPI for EnvBaseURL.
+const (
+	// EnvAddress is the environment variable holding the full address of a mailbox,
+	// e.g. foo@1secmail.com.
+	EnvAddress = "ONESECMAIL_ADDRESS"
+	// EnvLogin is the environment variable holding the login of a mailbox.
+	EnvLogin = "ONESECMAIL_LOGIN"
+	// EnvDomain is the environment variable holding the domain of a mailbox.
+	EnvDomain = "ONESECMAIL_DOMAIN"
+	// EnvBaseURL is the environment variable holding the base URL of the API, used
+	// if none is set with WithBaseURL, e.g. if 1secmail moves to a new host.
+	EnvBaseURL = "ONESECMAIL_BASE_URL"
+)
+
+// NewMailboxFromEnv returns a new Mailbox for the address in the ONESECMAIL_ADDRESS
+// environment variable, or for the login and domain in ONESECMAIL_LOGIN and
+// ONESECMAIL_DOMAIN. ONESECMAIL_ADDRESS takes precedence if it is set. The Mailbox
+// is configured by opts, as in NewAPI.
+func NewMailboxFromEnv(opts ...Option) (Mailbox, error) {
+	if address := os.Getenv(EnvAddress); address != "" {
+		return NewMailboxWithAddress(address, opts...)
+	}
+	login, domain := os.Getenv(EnvLogin), os.Getenv(EnvDomain)
+	if login == "" || domain == "" {
+		return Mailbox{}, fmt.Errorf("mailbox from environment failed: set %s, or both %s and %s",
+			EnvAddress, EnvLogin, EnvDomain)
+	}
+	return NewMailbox(login, domain, opts...)
+}
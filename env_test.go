@@ -0,0 +1,115 @@
+package onesecmail_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/z11i/onesecmail"
+)
+
+func Test_NewMailboxFromEnv(t *testing.T) {
+	tests := []struct {
+		name       string
+		env        map[string]string
+		expAddress string
+		expErr     string
+		expIs      error
+	}{
+		{
+			name:       "address",
+			env:        map[string]string{"ONESECMAIL_ADDRESS": "foo@1secmail.com"},
+			expAddress: "foo@1secmail.com",
+		},
+		{
+			name:       "login and domain",
+			env:        map[string]string{"ONESECMAIL_LOGIN": "bar", "ONESECMAIL_DOMAIN": "1secmail.org"},
+			expAddress: "bar@1secmail.org",
+		},
+		{
+			name: "address takes precedence",
+			env: map[string]string{
+				"ONESECMAIL_ADDRESS": "foo@1secmail.com", "ONESECMAIL_LOGIN": "bar", "ONESECMAIL_DOMAIN": "1secmail.org",
+			},
+			expAddress: "foo@1secmail.com",
+		},
+		{name: "nothing set", expErr: "set ONESECMAIL_ADDRESS, or both ONESECMAIL_LOGIN and ONESECMAIL_DOMAIN"},
+		{name: "login only", env: map[string]string{"ONESECMAIL_LOGIN": "bar"}, expErr: "ONESECMAIL_DOMAIN"},
+		{name: "invalid address", env: map[string]string{"ONESECMAIL_ADDRESS": "foo"}, expIs: onesecmail.ErrInvalidAddress},
+		{
+			name:  "invalid domain",
+			env:   map[string]string{"ONESECMAIL_LOGIN": "bar", "ONESECMAIL_DOMAIN": "example.com"},
+			expIs: onesecmail.ErrInvalidDomain,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for _, key := range []string{"ONESECMAIL_ADDRESS", "ONESECMAIL_LOGIN", "ONESECMAIL_DOMAIN"} {
+				t.Setenv(key, test.env[key])
+			}
+			mailbox, err := onesecmail.NewMailboxFromEnv()
+			if test.expErr == "" && test.expIs == nil {
+				if err != nil {
+					t.Fatalf("should not error: %v", err)
+				}
+				if mailbox.Address() != test.expAddress {
+					t.Fatalf("address expected: %s, got: %s", test.expAddress, mailbox.Address())
+				}
+				return
+			}
+			if err == nil {
+				t.Fatal("should error")
+			}
+			if test.expErr != "" && !strings.Contains(err.Error(), test.expErr) {
+				t.Fatalf("error expected: %s, got: %v", test.expErr, err)
+			}
+			if test.expIs != nil && !errors.Is(err, test.expIs) {
+				t.Fatalf("error expected to wrap: %v, got: %v", test.expIs, err)
+			}
+		})
+	}
+}
+
+func Test_BaseURLFromEnv(t *testing.T) {
+	var gotURL string
+	client := &ClientMock{DoFunc: func(req *http.Request) (*http.Response, error) {
+		gotURL = req.URL.Scheme + "://" + req.URL.Host + req.URL.Path
+		return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(`[]`))}, nil
+	}}
+	tests := []struct {
+		name   string
+		env    string
+		opts   []onesecmail.Option
+		expURL string
+	}{
+		{name: "default", expURL: "https://www.1secmail.com/api/v1/"},
+		{name: "environment", env: "http://127.0.0.1:8080/api/", expURL: "http://127.0.0.1:8080/api/"},
+		{
+			name:   "option wins",
+			env:    "http://127.0.0.1:8080/api/",
+			opts:   []onesecmail.Option{onesecmail.WithBaseURL("http://127.0.0.1:9090/")},
+			expURL: "http://127.0.0.1:9090/",
+		},
+		{
+			name:   "empty option",
+			env:    "http://127.0.0.1:8080/api/",
+			opts:   []onesecmail.Option{onesecmail.WithBaseURL("")},
+			expURL: "http://127.0.0.1:8080/api/",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			t.Setenv("ONESECMAIL_BASE_URL", test.env)
+			api := onesecmail.NewAPI(append([]onesecmail.Option{onesecmail.WithHTTPClient(client)}, test.opts...)...)
+			if _, err := api.Domains(context.Background()); err != nil {
+				t.Fatalf("should not error: %v", err)
+			}
+			if gotURL != test.expURL {
+				t.Fatalf("URL expected: %s, got: %s", test.expURL, gotURL)
+			}
+		})
+	}
+}
@@ -0,0 +1,86 @@
+package onesecmail
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+var (
+	// ErrInvalidDomain is returned when a domain is not one that 1secmail supports.
+	ErrInvalidDomain = errors.New("invalid domain")
+	// ErrInvalidAddress is returned when an email address cannot be parsed.
+	ErrInvalidAddress = errors.New("invalid email address")
+	// ErrMessageNotFound is returned when the API reports that a mail does not exist.
+	ErrMessageNotFound = errors.New("message not found")
+	// ErrRateLimited is returned when the API rejects a request for exceeding its rate limit.
+	ErrRateLimited = errors.New("rate limited")
+	// ErrHTTPStatus is matched by any *APIError caused by an unexpected status code.
+	ErrHTTPStatus = errors.New("unexpected HTTP status")
+	// ErrDecodeJSON is returned when a response from the API cannot be decoded.
+	ErrDecodeJSON = errors.New("decode JSON failed")
+	// ErrResponseTooLarge is returned when a response from the API exceeds the maximum
+	// size set by WithMaxResponseBytes.
+	ErrResponseTooLarge = errors.New("response too large")
+	// ErrInvalidCount is returned by RandomAddresses when the number of addresses
+	// asked for is not between 1 and MaxRandomAddresses.
+	ErrInvalidCount = errors.New("invalid count")
+	// ErrNoMessages is returned when an inbox is empty but a mail is expected.
+	ErrNoMessages = errors.New("no messages")
+	// ErrEmptyInbox is returned by Oldest and Newest when an inbox is empty. It is the
+	// same error as ErrNoMessages.
+	ErrEmptyInbox = ErrNoMessages
+)
+
+// APIError is returned when a request to the 1secmail API fails, either because
+// the request could not be made, or because the API responded with an unexpected
+// status code.
+type APIError struct {
+	// StatusCode is the HTTP status code of the response, or 0 if there was no response.
+	StatusCode int
+	// Action is the API action of the failed request, e.g. "getMessages".
+	Action string
+	// Message describes the failed operation.
+	Message string
+	// Body is the beginning of the response body, truncated to maxErrorBodySize bytes.
+	Body string
+	// Err is the underlying error, if any.
+	Err error
+}
+
+func (e *APIError) Error() string {
+	msg := e.Message
+	if e.StatusCode != 0 {
+		msg = fmt.Sprintf("%s, error code: %d", msg, e.StatusCode)
+	}
+	if e.Err != nil {
+		msg = fmt.Sprintf("%s: %s", msg, e.Err)
+	}
+	return msg
+}
+
+func (e *APIError) Unwrap() error {
+	return e.Err
+}
+
+// Is reports whether e matches target. An APIError with a status code matches ErrHTTPStatus.
+func (e *APIError) Is(target error) bool {
+	return target == ErrHTTPStatus && e.StatusCode != 0
+}
+
+// maxErrorBodySize is the maximum number of bytes of a response body kept in an APIError.
+const maxErrorBodySize = 512
+
+// statusError returns the sentinel error matching an unexpected status code of an action, if any.
+func statusError(action string, statusCode int) error {
+	switch {
+	case statusCode == http.StatusTooManyRequests:
+		return ErrRateLimited
+	case statusCode == http.StatusNotFound:
+		switch action {
+		case readMessage.String(), download.String(), deleteMessage.String():
+			return ErrMessageNotFound
+		}
+	}
+	return nil
+}
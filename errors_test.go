@@ -0,0 +1,130 @@
+package onesecmail_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/z11i/onesecmail"
+)
+
+func Test_APIError(t *testing.T) {
+	tests := []struct {
+		name      string
+		respCode  int
+		respBody  string
+		respErr   error
+		call      func(mb onesecmail.Mailbox) error
+		expAction string
+		expCode   int
+		expIs     error
+	}{
+		{
+			name:      "rate limited",
+			respCode:  429,
+			call:      func(mb onesecmail.Mailbox) error { _, err := mb.CheckInbox(context.Background()); return err },
+			expAction: "getMessages",
+			expCode:   429,
+			expIs:     onesecmail.ErrRateLimited,
+		},
+		{
+			name:      "message not found",
+			respCode:  404,
+			call:      func(mb onesecmail.Mailbox) error { _, err := mb.ReadMessage(context.Background(), 1); return err },
+			expAction: "readMessage",
+			expCode:   404,
+			expIs:     onesecmail.ErrMessageNotFound,
+		},
+		{
+			name:      "server error",
+			respCode:  500,
+			respBody:  strings.Repeat("x", 1000),
+			call:      func(mb onesecmail.Mailbox) error { _, err := mb.Domains(context.Background()); return err },
+			expAction: "getDomainList",
+			expCode:   500,
+		},
+		{
+			name:      "transport error",
+			respErr:   context.Canceled,
+			call:      func(mb onesecmail.Mailbox) error { _, err := mb.RandomAddresses(context.Background(), 1); return err },
+			expAction: "genRandomMailbox",
+			expIs:     context.Canceled,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := &ClientMock{
+				DoFunc: func(req *http.Request) (*http.Response, error) {
+					if test.respErr != nil {
+						return nil, test.respErr
+					}
+					return &http.Response{StatusCode: test.respCode, Body: ioutil.NopCloser(strings.NewReader(test.respBody))}, nil
+				},
+			}
+			mailbox, err := onesecmail.NewMailbox("foo", "1secmail.org", onesecmail.WithHTTPClient(client))
+			if err != nil {
+				t.Fatal("should not error")
+			}
+			err = test.call(mailbox)
+			var apiErr *onesecmail.APIError
+			if !errors.As(err, &apiErr) {
+				t.Fatalf("expected *APIError, got: %v", err)
+			}
+			if apiErr.Action != test.expAction {
+				t.Fatalf("action expected: %s, got: %s", test.expAction, apiErr.Action)
+			}
+			if apiErr.StatusCode != test.expCode {
+				t.Fatalf("status code expected: %d, got: %d", test.expCode, apiErr.StatusCode)
+			}
+			if expBody := test.respBody; len(expBody) > 512 {
+				if apiErr.Body != expBody[:512] {
+					t.Fatalf("body should be truncated to 512 bytes, got %d bytes", len(apiErr.Body))
+				}
+			}
+			if (test.expCode != 0) != errors.Is(err, onesecmail.ErrHTTPStatus) {
+				t.Fatalf("expected error with status code to be ErrHTTPStatus, got: %v", err)
+			}
+			if test.expIs != nil && !errors.Is(err, test.expIs) {
+				t.Fatalf("expected error to be %v, got: %v", test.expIs, err)
+			}
+		})
+	}
+}
+
+func Test_SentinelErrors(t *testing.T) {
+	_, err := onesecmail.NewMailbox("foo", "foobar.com", nil)
+	if !errors.Is(err, onesecmail.ErrInvalidDomain) {
+		t.Fatalf("expected ErrInvalidDomain, got: %v", err)
+	}
+	_, err = onesecmail.NewMailboxWithAddress("foobar.com", nil)
+	if !errors.Is(err, onesecmail.ErrInvalidAddress) {
+		t.Fatalf("expected ErrInvalidAddress, got: %v", err)
+	}
+
+	client := &ClientMock{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			return &http.Response{StatusCode: 200, Body: ioutil.NopCloser(strings.NewReader(`[`))}, nil
+		},
+	}
+	mailbox, err := onesecmail.NewMailbox("foo", "1secmail.org", onesecmail.WithHTTPClient(client))
+	if err != nil {
+		t.Fatal("should not error")
+	}
+	_, err = mailbox.CheckInbox(context.Background())
+	if !errors.Is(err, onesecmail.ErrDecodeJSON) {
+		t.Fatalf("expected ErrDecodeJSON, got: %v", err)
+	}
+	if errors.Is(err, onesecmail.ErrHTTPStatus) {
+		t.Fatalf("decode error should not be ErrHTTPStatus: %v", err)
+	}
+}
+
+func Test_InvalidLogin(t *testing.T) {
+	_, err := onesecmail.NewMailbox("foo bar", "1secmail.com")
+	if !errors.Is(err, onesecmail.ErrInvalidAddress) {
+		t.Fatalf("expected ErrInvalidAddress, got: %v", err)
+	}
+}
@@ -0,0 +1,67 @@
+package onesecmail
+
+import "context"
+
+// EventKind is the kind of an InboxEvent.
+type EventKind int
+
+const (
+	// EventNewMessage reports a mail that has not been seen before.
+	EventNewMessage EventKind = iota
+	// EventError reports an error from checking the inbox.
+	EventError
+	// EventClosed is the last event sent, after which the channel is closed.
+	EventClosed
+)
+
+func (k EventKind) String() string {
+	return [...]string{"new message", "error", "closed"}[k]
+}
+
+// InboxEvent is an event of an inbox watched by Events.
+type InboxEvent struct {
+	Kind EventKind
+	// Mail is the new mail of an EventNewMessage.
+	Mail *Mail
+	// Err is the error of an EventError, or the reason of an EventClosed.
+	Err error
+}
+
+// Events is like WatchWithOptions, but sends the new mails and the errors as events
+// on a single channel. Once watching stops, an EventClosed is sent with the error of
+// ctx, or the last error once opts.MaxConsecutiveErrors is reached, and the channel
+// is closed. If ctx is done and the channel is full, the EventClosed is dropped.
+func (m Mailbox) Events(ctx context.Context, opts WatchOptions) <-chan InboxEvent {
+	if opts.BufferSize <= 0 {
+		opts.BufferSize = watchBufferSize
+	}
+	ch := make(chan InboxEvent, opts.BufferSize)
+	send := func(event InboxEvent) bool {
+		select {
+		case ch <- event:
+			return true
+		case <-ctx.Done():
+			return false
+		}
+	}
+	go func() {
+		defer close(ch)
+		var lastErr error
+		m.poll(ctx, opts, func(mail *Mail) bool {
+			return send(InboxEvent{Kind: EventNewMessage, Mail: mail})
+		}, func(err error) bool {
+			lastErr = err
+			return send(InboxEvent{Kind: EventError, Err: err})
+		})
+
+		if err := ctx.Err(); err != nil {
+			select {
+			case ch <- InboxEvent{Kind: EventClosed, Err: err}:
+			default:
+			}
+			return
+		}
+		send(InboxEvent{Kind: EventClosed, Err: lastErr})
+	}()
+	return ch
+}
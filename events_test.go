@@ -0,0 +1,88 @@
+package onesecmail_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/z11i/onesecmail"
+)
+
+// collectEvents receives events from ch until it is closed, failing the test if
+// that takes longer than a second.
+func collectEvents(t *testing.T, ch <-chan onesecmail.InboxEvent, cancel context.CancelFunc) []onesecmail.InboxEvent {
+	t.Helper()
+	var events []onesecmail.InboxEvent
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case event, ok := <-ch:
+			if !ok {
+				return events
+			}
+			events = append(events, event)
+			if cancel != nil && event.Kind == onesecmail.EventNewMessage && event.Mail.ID == 640 {
+				cancel()
+			}
+		case <-timeout:
+			t.Fatal("channel should be closed")
+		}
+	}
+}
+
+func Test_Events(t *testing.T) {
+	client := scriptedClient(
+		scriptedResponse{200, `[{"id":639}]`},
+		scriptedResponse{500, ""},
+		scriptedResponse{200, `[{"id":639},{"id":640}]`},
+	)
+	mailbox, err := onesecmail.NewMailbox("foo", "1secmail.org", onesecmail.WithHTTPClient(client))
+	if err != nil {
+		t.Fatal("should not error")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	events := collectEvents(t, mailbox.Events(ctx, onesecmail.WatchOptions{Interval: time.Millisecond}), cancel)
+	expKinds := []onesecmail.EventKind{
+		onesecmail.EventNewMessage, onesecmail.EventError, onesecmail.EventNewMessage, onesecmail.EventClosed,
+	}
+	if len(events) != len(expKinds) {
+		t.Fatalf("%d events expected, got: %v", len(expKinds), events)
+	}
+	for i, kind := range expKinds {
+		if events[i].Kind != kind {
+			t.Fatalf("event %d expected: %s, got: %s", i, kind, events[i].Kind)
+		}
+	}
+	if events[0].Mail.ID != 639 || events[2].Mail.ID != 640 {
+		t.Fatalf("mails 639 and 640 expected, got: %+v, %+v", events[0].Mail, events[2].Mail)
+	}
+	if !errors.Is(events[1].Err, onesecmail.ErrHTTPStatus) {
+		t.Fatalf("expected ErrHTTPStatus, got: %v", events[1].Err)
+	}
+	if !errors.Is(events[3].Err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got: %v", events[3].Err)
+	}
+}
+
+func Test_Events_MaxConsecutiveErrors(t *testing.T) {
+	client := scriptedClient(scriptedResponse{500, ""})
+	mailbox, err := onesecmail.NewMailbox("foo", "1secmail.org", onesecmail.WithHTTPClient(client))
+	if err != nil {
+		t.Fatal("should not error")
+	}
+
+	events := collectEvents(t, mailbox.Events(context.Background(), onesecmail.WatchOptions{
+		Interval:             time.Millisecond,
+		MaxConsecutiveErrors: 2,
+	}), nil)
+	if len(events) != 3 || events[0].Kind != onesecmail.EventError || events[1].Kind != onesecmail.EventError ||
+		events[2].Kind != onesecmail.EventClosed {
+		t.Fatalf("2 errors and a close expected, got: %v", events)
+	}
+	if !errors.Is(events[2].Err, onesecmail.ErrHTTPStatus) {
+		t.Fatalf("close should report the last error, got: %v", events[2].Err)
+	}
+}
@@ -0,0 +1,167 @@
+package onesecmail
+
+import (
+	"errors"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+// ErrNoOTPFound is returned when no one-time code is found in a mail.
+var ErrNoOTPFound = errors.New("no one-time code found")
+
+// ErrNoVerificationURL is returned when no verification link is found in a mail.
+var ErrNoVerificationURL = errors.New("no verification URL found")
+
+// verificationKeywords are the words that identify a verification link.
+var verificationKeywords = []string{"verify", "confirm", "activate", "magic", "token", "validate"}
+
+// defaultOTPPattern matches a code of 4 to 8 digits not surrounded by other digits.
+var defaultOTPPattern = regexp.MustCompile(`(?:^|\D)(\d{4,8})(?:\D|$)`)
+
+// DefaultOTPPattern returns a pattern that matches common one-time codes of 4 to 8
+// digits, e.g. in "Your verification code is 123456".
+func DefaultOTPPattern() *regexp.Regexp {
+	return defaultOTPPattern
+}
+
+// ExtractCode searches the bodies of a mail for pattern, and returns the first capture
+// group of the first match, or the whole match if pattern has no capture group. The
+// TextBody is searched first, then the HTMLBody and the Body, stripped of HTML tags
+// so that digits in markup are not mistaken for a code. If pattern is nil,
+// DefaultOTPPattern is used. It reports false if no body matches.
+func (m *Mail) ExtractCode(pattern *regexp.Regexp) (string, bool) {
+	if pattern == nil {
+		pattern = defaultOTPPattern
+	}
+	for _, body := range m.textBodies() {
+		if match := pattern.FindStringSubmatch(body); match != nil {
+			if len(match) > 1 {
+				return match[1], true
+			}
+			return match[0], true
+		}
+	}
+	return "", false
+}
+
+// ExtractOTP returns the first one-time code of 4 to 8 digits in a mail, as matched by
+// DefaultOTPPattern. The TextBody is searched first, then the HTMLBody and the Body,
+// stripped of HTML tags so that digits in markup are not mistaken for a code. If no
+// code is found, ErrNoOTPFound is returned.
+func (m *Mail) ExtractOTP() (string, error) {
+	return m.ExtractCodeWithPattern(defaultOTPPattern)
+}
+
+// ExtractCodeWithPattern is like ExtractOTP, but searches for re instead, so that
+// codes such as "ABC-123" can be found. It returns the same code as ExtractCode, or
+// ErrNoOTPFound if there is none.
+func (m *Mail) ExtractCodeWithPattern(re *regexp.Regexp) (string, error) {
+	if re == nil {
+		return "", errors.New("extract code failed: nil pattern")
+	}
+	if code, ok := m.ExtractCode(re); ok {
+		return code, nil
+	}
+	return "", ErrNoOTPFound
+}
+
+// urlRe matches http and https URLs in plain text.
+var urlRe = regexp.MustCompile(`https?://[^\s<>"'()]+[^\s<>"'().,;:!?]`)
+
+// ExtractLinks returns the links in a mail, in the order they appear and without
+// duplicates. If the mail has an HTMLBody that is not blank, the href attributes of
+// its anchors and the src attributes of its images are returned, except for
+// javascript: links. Relative links are returned as they are. Otherwise the http and
+// https URLs in its TextBody are returned, as for plain text mails, which 1secmail
+// returns with an empty HTMLBody. If neither body is set, an empty slice is returned.
+func (m *Mail) ExtractLinks() []string {
+	links := []string{}
+	seen := make(map[string]struct{})
+	add := func(link string) {
+		link = strings.TrimSpace(link)
+		if link == "" || strings.HasPrefix(strings.ToLower(link), "javascript:") {
+			return
+		}
+		if _, ok := seen[link]; ok {
+			return
+		}
+		seen[link] = struct{}{}
+		links = append(links, link)
+	}
+
+	switch {
+	case m.HTMLBody != nil && strings.TrimSpace(*m.HTMLBody) != "":
+		z := html.NewTokenizer(strings.NewReader(*m.HTMLBody))
+		for {
+			tt := z.Next()
+			if tt == html.ErrorToken {
+				break
+			}
+			if tt != html.StartTagToken && tt != html.SelfClosingTagToken {
+				continue
+			}
+			token := z.Token()
+			var attr string
+			switch token.Data {
+			case "a":
+				attr = "href"
+			case "img":
+				attr = "src"
+			default:
+				continue
+			}
+			for _, a := range token.Attr {
+				if a.Key == attr {
+					add(a.Val)
+				}
+			}
+		}
+	case m.TextBody != nil:
+		for _, link := range urlRe.FindAllString(*m.TextBody, -1) {
+			add(link)
+		}
+	}
+	return links
+}
+
+// ExtractVerificationURL returns the verification link in a mail, such as a magic
+// link of a passwordless login, found among its ExtractLinks. A verification link is
+// an http or https URL whose path or query contains one of "verify", "confirm",
+// "activate", "magic", "token" or "validate", ignoring case. If several links match,
+// https links are preferred, then links with longer paths. If no link matches,
+// ErrNoVerificationURL is returned.
+func (m *Mail) ExtractVerificationURL() (string, error) {
+	type candidate struct {
+		link string
+		u    *url.URL
+	}
+	var candidates []candidate
+	for _, link := range m.ExtractLinks() {
+		u, err := url.Parse(link)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") {
+			continue
+		}
+		target := strings.ToLower(u.Path + "?" + u.RawQuery)
+		for _, keyword := range verificationKeywords {
+			if strings.Contains(target, keyword) {
+				candidates = append(candidates, candidate{link: link, u: u})
+				break
+			}
+		}
+	}
+	if len(candidates) == 0 {
+		return "", ErrNoVerificationURL
+	}
+	sort.SliceStable(candidates, func(i, j int) bool {
+		ci, cj := candidates[i].u, candidates[j].u
+		if ci.Scheme != cj.Scheme {
+			return ci.Scheme == "https"
+		}
+		return len(ci.Path) > len(cj.Path)
+	})
+	return candidates[0].link, nil
+}
@@ -0,0 +1,251 @@
+package onesecmail_test
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+
+	"github.com/z11i/onesecmail"
+)
+
+func strPtr(s string) *string {
+	return &s
+}
+
+func Test_ExtractCode(t *testing.T) {
+	tests := []struct {
+		name    string
+		mail    onesecmail.Mail
+		pattern *regexp.Regexp
+		expCode string
+		expOK   bool
+	}{
+		{
+			name:    "default pattern in text body",
+			mail:    onesecmail.Mail{TextBody: strPtr("Your verification code is 123456.")},
+			expCode: "123456",
+			expOK:   true,
+		},
+		{
+			name:    "text body before html body",
+			mail:    onesecmail.Mail{TextBody: strPtr("code: 1111"), HTMLBody: strPtr("<b>2222</b>")},
+			expCode: "1111",
+			expOK:   true,
+		},
+		{
+			name:    "falls back to html body and body",
+			mail:    onesecmail.Mail{TextBody: strPtr("no code here"), Body: strPtr("<p>Use 98765432</p>")},
+			expCode: "98765432",
+			expOK:   true,
+		},
+		{
+			name:    "digits in html attributes",
+			mail:    onesecmail.Mail{HTMLBody: strPtr(`<table width="1200"><tr><td>Your code is 482913</td></tr></table>`)},
+			expCode: "482913",
+			expOK:   true,
+		},
+		{
+			name: "too long to be a code",
+			mail: onesecmail.Mail{TextBody: strPtr("Order 1234567890")},
+		},
+		{
+			name:    "custom pattern with capture group",
+			mail:    onesecmail.Mail{HTMLBody: strPtr("Your code: ABC-123")},
+			pattern: regexp.MustCompile(`code: ([A-Z]{3}-\d{3})`),
+			expCode: "ABC-123",
+			expOK:   true,
+		},
+		{
+			name:    "custom pattern without capture group",
+			mail:    onesecmail.Mail{TextBody: strPtr("token XYZ9")},
+			pattern: regexp.MustCompile(`XYZ\d`),
+			expCode: "XYZ9",
+			expOK:   true,
+		},
+		{
+			name: "nil bodies",
+			mail: onesecmail.Mail{},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			code, ok := test.mail.ExtractCode(test.pattern)
+			if ok != test.expOK || code != test.expCode {
+				t.Fatalf("expected (%q, %v), got: (%q, %v)", test.expCode, test.expOK, code, ok)
+			}
+		})
+	}
+}
+
+func Test_ExtractLinks(t *testing.T) {
+	tests := []struct {
+		name string
+		mail onesecmail.Mail
+		exp  []string
+	}{
+		{name: "no body", mail: onesecmail.Mail{}, exp: []string{}},
+		{
+			name: "html anchors and images",
+			mail: onesecmail.Mail{HTMLBody: strPtr(`<p><a href="https://example.com/verify?token=abc&amp;x=1">Verify</a><img src="https://cdn.example.com/logo.png"/><a href="/relative/path">Relative</a><a HREF="https://example.com/verify?token=abc&amp;x=1">Again</a></p>`)},
+			exp:  []string{"https://example.com/verify?token=abc&x=1", "https://cdn.example.com/logo.png", "/relative/path"},
+		},
+		{
+			name: "javascript hrefs skipped",
+			mail: onesecmail.Mail{HTMLBody: strPtr(`<a href="javascript:void(0)">x</a><a href=" JavaScript:alert(1)">y</a><a href="">z</a><a>no href</a>`)},
+			exp:  []string{},
+		},
+		{
+			name: "malformed html",
+			mail: onesecmail.Mail{HTMLBody: strPtr(`<div><a href="https://example.com/a">a<a href='https://example.com/b'<img src=x`)},
+			exp:  []string{"https://example.com/a"},
+		},
+		{
+			name: "text body",
+			mail: onesecmail.Mail{TextBody: strPtr("Visit https://example.com/confirm?id=1. Or (http://example.org/x), or https://example.com/confirm?id=1 again.")},
+			exp:  []string{"https://example.com/confirm?id=1", "http://example.org/x"},
+		},
+		{
+			name: "empty html body",
+			mail: onesecmail.Mail{TextBody: strPtr("Verify: https://example.com/verify?t=1"), HTMLBody: strPtr(""), Body: strPtr("Verify: https://example.com/verify?t=1")},
+			exp:  []string{"https://example.com/verify?t=1"},
+		},
+		{
+			name: "blank html body",
+			mail: onesecmail.Mail{TextBody: strPtr("https://example.com/a"), HTMLBody: strPtr(" \n")},
+			exp:  []string{"https://example.com/a"},
+		},
+		{
+			name: "html body preferred over text body",
+			mail: onesecmail.Mail{TextBody: strPtr("https://text.example.com"), HTMLBody: strPtr(`<a href="https://html.example.com">x</a>`)},
+			exp:  []string{"https://html.example.com"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := test.mail.ExtractLinks()
+			if got == nil {
+				t.Fatal("links should not be nil")
+			}
+			if len(got) != len(test.exp) {
+				t.Fatalf("links expected: %q, got: %q", test.exp, got)
+			}
+			for i := range got {
+				if got[i] != test.exp[i] {
+					t.Fatalf("links expected: %q, got: %q", test.exp, got)
+				}
+			}
+		})
+	}
+}
+
+func Test_ExtractOTP(t *testing.T) {
+	tests := []struct {
+		name    string
+		mail    onesecmail.Mail
+		expCode string
+		expErr  error
+	}{
+		{name: "text body", mail: onesecmail.Mail{TextBody: strPtr("Your code is 4821.")}, expCode: "4821"},
+		{name: "adjacent letters", mail: onesecmail.Mail{TextBody: strPtr("code:987654;")}, expCode: "987654"},
+		{name: "first of several", mail: onesecmail.Mail{TextBody: strPtr("1234 then 5678")}, expCode: "1234"},
+		{name: "too short and too long", mail: onesecmail.Mail{TextBody: strPtr("123 and 123456789")}, expErr: onesecmail.ErrNoOTPFound},
+		{
+			name:    "html markup ignored",
+			mail:    onesecmail.Mail{HTMLBody: strPtr(`<p style="color:#123456" width="1200">Code: <b>246810</b></p>`)},
+			expCode: "246810",
+		},
+		{name: "nil bodies", mail: onesecmail.Mail{}, expErr: onesecmail.ErrNoOTPFound},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			code, err := test.mail.ExtractOTP()
+			if !errors.Is(err, test.expErr) {
+				t.Fatalf("error expected: %v, got: %v", test.expErr, err)
+			}
+			if code != test.expCode {
+				t.Fatalf("code expected: %q, got: %q", test.expCode, code)
+			}
+		})
+	}
+}
+
+func Test_ExtractCodeWithPattern(t *testing.T) {
+	mail := onesecmail.Mail{HTMLBody: strPtr("<p>Your code: <b>ABC-123</b></p>")}
+	code, err := mail.ExtractCodeWithPattern(regexp.MustCompile(`[A-Z]{3}-\d{3}`))
+	if err != nil || code != "ABC-123" {
+		t.Fatalf("expected ABC-123, got: %q, %v", code, err)
+	}
+	if _, err := mail.ExtractCodeWithPattern(regexp.MustCompile(`XYZ`)); !errors.Is(err, onesecmail.ErrNoOTPFound) {
+		t.Fatalf("expected ErrNoOTPFound, got: %v", err)
+	}
+	if _, err := mail.ExtractCodeWithPattern(nil); err == nil {
+		t.Fatal("nil pattern should error")
+	}
+
+	// Both extractors find the same code in HTML mail.
+	mail = onesecmail.Mail{HTMLBody: strPtr(`<table width="1200"><tr><td>Your code is 482913</td></tr></table>`)}
+	code, err = mail.ExtractCodeWithPattern(onesecmail.DefaultOTPPattern())
+	if other, ok := mail.ExtractCode(nil); err != nil || !ok || code != other {
+		t.Fatalf("same code expected, got: %q, %v and %q, %v", code, err, other, ok)
+	}
+}
+
+func Test_ExtractVerificationURL(t *testing.T) {
+	tests := []struct {
+		name   string
+		mail   onesecmail.Mail
+		expURL string
+		expErr error
+	}{
+		{
+			name:   "magic link",
+			mail:   onesecmail.Mail{HTMLBody: strPtr(`<a href="https://example.com/help">Help</a><a href="https://example.com/auth/magic?code=abc">Log in</a>`)},
+			expURL: "https://example.com/auth/magic?code=abc",
+		},
+		{
+			name:   "keyword in query ignoring case",
+			mail:   onesecmail.Mail{TextBody: strPtr("Click https://example.com/login?Token=xyz to continue")},
+			expURL: "https://example.com/login?Token=xyz",
+		},
+		{
+			name: "plain text mail from the API",
+			mail: onesecmail.Mail{
+				TextBody: strPtr("Verify your account: https://example.com/verify?t=1\n"),
+				HTMLBody: strPtr(""),
+				Body:     strPtr("Verify your account: https://example.com/verify?t=1\n"),
+			},
+			expURL: "https://example.com/verify?t=1",
+		},
+		{
+			name:   "https preferred",
+			mail:   onesecmail.Mail{HTMLBody: strPtr(`<a href="http://example.com/account/verify/long/path">a</a><a href="https://example.com/verify">b</a>`)},
+			expURL: "https://example.com/verify",
+		},
+		{
+			name:   "longer path preferred",
+			mail:   onesecmail.Mail{HTMLBody: strPtr(`<a href="https://example.com/confirm">a</a><a href="https://example.com/account/confirm/123">b</a>`)},
+			expURL: "https://example.com/account/confirm/123",
+		},
+		{
+			name:   "relative and non-http links ignored",
+			mail:   onesecmail.Mail{HTMLBody: strPtr(`<a href="/verify">a</a><a href="mailto:verify@example.com">b</a>`)},
+			expErr: onesecmail.ErrNoVerificationURL,
+		},
+		{
+			name:   "no links",
+			mail:   onesecmail.Mail{},
+			expErr: onesecmail.ErrNoVerificationURL,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := test.mail.ExtractVerificationURL()
+			if !errors.Is(err, test.expErr) {
+				t.Fatalf("error expected: %v, got: %v", test.expErr, err)
+			}
+			if got != test.expURL {
+				t.Fatalf("URL expected: %q, got: %q", test.expURL, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,165 @@
+package onesecmail
+
+import (
+	"context"
+	"errors"
+	"net/mail"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// InboxFilter selects mails in an inbox. Every field that is set must match for a
+// mail to be selected; a zero InboxFilter selects every mail.
+type InboxFilter struct {
+	// FromContains selects mails whose sender contains it, ignoring case.
+	FromContains string
+	// SubjectContains selects mails whose subject contains it, ignoring case.
+	SubjectContains string
+	// After selects mails received after it.
+	After time.Time
+	// Before selects mails received before it.
+	Before time.Time
+	// HasAttachment selects mails with at least one attachment.
+	HasAttachment bool
+}
+
+// WithFromContains returns a copy of f that selects mails whose sender contains s.
+func (f InboxFilter) WithFromContains(s string) InboxFilter {
+	f.FromContains = s
+	return f
+}
+
+// WithSubjectContains returns a copy of f that selects mails whose subject contains s.
+func (f InboxFilter) WithSubjectContains(s string) InboxFilter {
+	f.SubjectContains = s
+	return f
+}
+
+// WithAfter returns a copy of f that selects mails received after t.
+func (f InboxFilter) WithAfter(t time.Time) InboxFilter {
+	f.After = t
+	return f
+}
+
+// WithBefore returns a copy of f that selects mails received before t.
+func (f InboxFilter) WithBefore(t time.Time) InboxFilter {
+	f.Before = t
+	return f
+}
+
+// WithHasAttachment returns a copy of f that selects mails with at least one attachment.
+func (f InboxFilter) WithHasAttachment() InboxFilter {
+	f.HasAttachment = true
+	return f
+}
+
+// Match reports whether mail is selected by f. A mail whose date cannot be parsed
+// is not selected if After or Before is set.
+func (f InboxFilter) Match(mail *Mail) bool {
+	if f.FromContains != "" && !strings.Contains(strings.ToLower(mail.From), strings.ToLower(f.FromContains)) {
+		return false
+	}
+	if f.SubjectContains != "" && !strings.Contains(strings.ToLower(mail.Subject), strings.ToLower(f.SubjectContains)) {
+		return false
+	}
+	if !f.After.IsZero() || !f.Before.IsZero() {
+		date, err := mail.ParseDate()
+		if err != nil {
+			return false
+		}
+		if !f.After.IsZero() && !date.After(f.After) {
+			return false
+		}
+		if !f.Before.IsZero() && !date.Before(f.Before) {
+			return false
+		}
+	}
+	if f.HasAttachment && len(mail.Attachments) == 0 {
+		return false
+	}
+	return true
+}
+
+// CheckInboxWithFilter checks the inbox of a mailbox, and returns the mails selected
+// by f. Mails listed in the inbox carry no attachment info, so if f.HasAttachment is
+// set, each mail otherwise selected is read with ReadMessage, and the full mails are
+// returned.
+func (m Mailbox) CheckInboxWithFilter(ctx context.Context, f InboxFilter) ([]*Mail, error) {
+	mails, err := m.CheckInbox(ctx)
+	if err != nil {
+		return nil, err
+	}
+	summaryFilter := f
+	summaryFilter.HasAttachment = false
+
+	var selected []*Mail
+	for _, mail := range mails {
+		if !summaryFilter.Match(mail) {
+			continue
+		}
+		if f.HasAttachment {
+			mail, err = m.ReadMessage(ctx, mail.ID)
+			if err != nil {
+				return nil, err
+			}
+			if !f.Match(mail) {
+				continue
+			}
+		}
+		selected = append(selected, mail)
+	}
+	return selected, nil
+}
+
+// FindBySender checks the inbox of a mailbox, and returns the mails from a sender,
+// ignoring case. If from is an address such as "noreply@example.com", the sender's
+// address must be equal to it. Otherwise from must be the domain of the sender's
+// address or one of its parent domains, so that "example.com" matches mails from
+// "noreply@mail.example.com", but not from "noreply@evil-example.com".
+func (m Mailbox) FindBySender(ctx context.Context, from string) ([]*Mail, error) {
+	from = strings.ToLower(from)
+	return m.findMails(ctx, func(mail *Mail) bool {
+		address := strings.ToLower(senderAddress(mail.From))
+		if strings.Contains(from, "@") {
+			return address == from
+		}
+		_, domain, _ := strings.Cut(address, "@")
+		return domain == from || strings.HasSuffix(domain, "."+from)
+	})
+}
+
+// FindBySenderRegexp checks the inbox of a mailbox, and returns the mails whose sender
+// matches pattern.
+func (m Mailbox) FindBySenderRegexp(ctx context.Context, pattern *regexp.Regexp) ([]*Mail, error) {
+	if pattern == nil {
+		return nil, errors.New("find by sender failed: nil sender pattern")
+	}
+	return m.findMails(ctx, func(mail *Mail) bool {
+		return pattern.MatchString(mail.From)
+	})
+}
+
+// findMails checks the inbox of a mailbox, and returns the mails that match predicate.
+func (m Mailbox) findMails(ctx context.Context, predicate func(*Mail) bool) ([]*Mail, error) {
+	mails, err := m.CheckInbox(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var found []*Mail
+	for _, mail := range mails {
+		if predicate(mail) {
+			found = append(found, mail)
+		}
+	}
+	return found, nil
+}
+
+// senderAddress returns the address in the From field of a mail, which may also
+// contain a display name, e.g. "Sender <user@example.com>".
+func senderAddress(from string) string {
+	if addr, err := mail.ParseAddress(from); err == nil {
+		return addr.Address
+	}
+	return strings.TrimSpace(from)
+}
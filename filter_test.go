@@ -0,0 +1,147 @@
+package onesecmail_test
+
+import (
+	"context"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/z11i/onesecmail"
+)
+
+func Test_CheckInboxWithFilter(t *testing.T) {
+	inbox := `[
+		{"id":1,"from":"notifications@github.com","subject":"New issue","date":"2018-06-08 10:00:00"},
+		{"id":2,"from":"noreply@example.com","subject":"Your invoice","date":"2018-06-08 12:00:00"},
+		{"id":3,"from":"Support@GitHub.com","subject":"Your invoice","date":"2018-06-08 14:00:00"},
+		{"id":4,"from":"someone@example.com","subject":"Hello","date":"bad date"}
+	]`
+	noon := time.Date(2018, 6, 8, 12, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name   string
+		filter onesecmail.InboxFilter
+		expIDs []int
+	}{
+		{name: "zero filter", filter: onesecmail.InboxFilter{}, expIDs: []int{1, 2, 3, 4}},
+		{name: "from", filter: onesecmail.InboxFilter{}.WithFromContains("github.com"), expIDs: []int{1, 3}},
+		{name: "subject", filter: onesecmail.InboxFilter{}.WithSubjectContains("INVOICE"), expIDs: []int{2, 3}},
+		{name: "after", filter: onesecmail.InboxFilter{}.WithAfter(noon), expIDs: []int{3}},
+		{name: "before", filter: onesecmail.InboxFilter{}.WithBefore(noon), expIDs: []int{1}},
+		{name: "has attachment", filter: onesecmail.InboxFilter{}.WithHasAttachment(), expIDs: []int{2}},
+		{name: "from and subject", filter: onesecmail.InboxFilter{}.WithFromContains("github").WithSubjectContains("invoice"), expIDs: []int{3}},
+		{name: "subject and before", filter: onesecmail.InboxFilter{}.WithSubjectContains("invoice").WithBefore(noon.Add(time.Hour)), expIDs: []int{2}},
+		{name: "no match", filter: onesecmail.InboxFilter{}.WithFromContains("github").WithHasAttachment(), expIDs: nil},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := &ClientMock{
+				DoFunc: func(req *http.Request) (*http.Response, error) {
+					query := req.URL.Query()
+					body := inbox
+					if query.Get("action") == "readMessage" {
+						attachments := `[]`
+						if query.Get("id") == "2" {
+							attachments = `[{"filename":"invoice.pdf","contentType":"application/pdf","size":1024}]`
+						}
+						body = `{"id":` + query.Get("id") + `,"from":"x","subject":"x","date":"2018-06-08 12:00:00","attachments":` + attachments + `}`
+					}
+					return &http.Response{StatusCode: 200, Body: ioutil.NopCloser(strings.NewReader(body))}, nil
+				},
+			}
+			mailbox, err := onesecmail.NewMailbox("foo", "1secmail.org", onesecmail.WithHTTPClient(client))
+			if err != nil {
+				t.Fatal("should not error")
+			}
+			mails, err := mailbox.CheckInboxWithFilter(context.Background(), test.filter)
+			if err != nil {
+				t.Fatalf("should not error: %v", err)
+			}
+			var ids []int
+			for _, mail := range mails {
+				ids = append(ids, mail.ID)
+			}
+			if len(ids) != len(test.expIDs) {
+				t.Fatalf("IDs expected: %v, got: %v", test.expIDs, ids)
+			}
+			for i := range ids {
+				if ids[i] != test.expIDs[i] {
+					t.Fatalf("IDs expected: %v, got: %v", test.expIDs, ids)
+				}
+			}
+		})
+	}
+}
+
+func Test_InboxFilter_BuilderDoesNotMutate(t *testing.T) {
+	base := onesecmail.InboxFilter{}.WithFromContains("github.com")
+	derived := base.WithSubjectContains("invoice")
+	if base.SubjectContains != "" {
+		t.Fatal("builder methods should return a copy")
+	}
+	if derived.FromContains != "github.com" || derived.SubjectContains != "invoice" {
+		t.Fatal("builder methods should keep existing fields")
+	}
+}
+
+func Test_FindBySender(t *testing.T) {
+	inbox := `[
+		{"id":1,"from":"notifications@github.com","subject":"a","date":"2018-06-08 10:00:00"},
+		{"id":2,"from":"GitHub <NoReply@GitHub.com>","subject":"b","date":"2018-06-08 11:00:00"},
+		{"id":3,"from":"noreply@mail.example.com","subject":"c","date":"2018-06-08 12:00:00"},
+		{"id":4,"from":"someone@example.org","subject":"d","date":"2018-06-08 13:00:00"},
+		{"id":5,"from":"noreply@evil-example.com","subject":"e","date":"2018-06-08 14:00:00"}
+	]`
+	tests := []struct {
+		name    string
+		from    string
+		pattern *regexp.Regexp
+		expIDs  []int
+		expErr  bool
+	}{
+		{name: "address ignoring case", from: "noreply@github.com", expIDs: []int{2}},
+		{name: "domain", from: "GITHUB.COM", expIDs: []int{1, 2}},
+		{name: "parent domain", from: "example.com", expIDs: []int{3}},
+		{name: "exact domain", from: "mail.example.com", expIDs: []int{3}},
+		{name: "domain suffix", from: "ample.com", expIDs: nil},
+		{name: "no match", from: "nobody@example.com", expIDs: nil},
+		{name: "regexp", pattern: regexp.MustCompile(`(?i)^noreply@`), expIDs: []int{3, 5}},
+		{name: "nil regexp", expErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := &ClientMock{
+				DoFunc: func(req *http.Request) (*http.Response, error) {
+					return &http.Response{StatusCode: 200, Body: ioutil.NopCloser(strings.NewReader(inbox))}, nil
+				},
+			}
+			mailbox, err := onesecmail.NewMailbox("foo", "1secmail.org", onesecmail.WithHTTPClient(client))
+			if err != nil {
+				t.Fatal("should not error")
+			}
+			var mails []*onesecmail.Mail
+			if test.from != "" {
+				mails, err = mailbox.FindBySender(context.Background(), test.from)
+			} else {
+				mails, err = mailbox.FindBySenderRegexp(context.Background(), test.pattern)
+			}
+			if (err == nil) != !test.expErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			var ids []int
+			for _, mail := range mails {
+				ids = append(ids, mail.ID)
+			}
+			if len(ids) != len(test.expIDs) {
+				t.Fatalf("IDs expected: %v, got: %v", test.expIDs, ids)
+			}
+			for i := range ids {
+				if ids[i] != test.expIDs[i] {
+					t.Fatalf("IDs expected: %v, got: %v", test.expIDs, ids)
+				}
+			}
+		})
+	}
+}
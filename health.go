@@ -0,0 +1,39 @@
+package onesecmail
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// APIStatus is the status of the 1secmail API, as returned by API.Status.
+type APIStatus struct {
+	// Latency is how long the API took to list its domains.
+	Latency time.Duration
+	// Domains are the domains that 1secmail supports.
+	Domains []string
+}
+
+// Status lists the domains of the API, and returns them with how long it took. The
+// domains are always requested, even if WithDomainCacheTTL is set.
+func (a API) Status(ctx context.Context) (APIStatus, error) {
+	start := time.Now()
+	domains, err := a.fetchDomains(ctx)
+	if err != nil {
+		return APIStatus{}, fmt.Errorf("health check failed: %w", err)
+	}
+	return APIStatus{Latency: time.Since(start), Domains: domains}, nil
+}
+
+// HealthCheck returns an error if the API cannot be reached, e.g. before a
+// long-running process starts polling. It lists the domains of the API, as Status
+// does.
+func (a API) HealthCheck(ctx context.Context) error {
+	_, err := a.Status(ctx)
+	return err
+}
+
+// IsReachable reports whether the API can be reached, as HealthCheck does.
+func (a API) IsReachable(ctx context.Context) bool {
+	return a.HealthCheck(ctx) == nil
+}
@@ -0,0 +1,70 @@
+package onesecmail_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/z11i/onesecmail"
+)
+
+func Test_HealthCheck(t *testing.T) {
+	tests := []struct {
+		name       string
+		respCode   int
+		respBody   string
+		respErr    error
+		expDomains []string
+		expErr     string
+	}{
+		{name: "healthy", respCode: 200, respBody: `["1secmail.com","1secmail.org"]`, expDomains: []string{"1secmail.com", "1secmail.org"}},
+		{name: "server error", respCode: 503, expErr: "health check failed: get domain list failed"},
+		{name: "malformed response", respCode: 200, respBody: `<html>`, expErr: "health check failed: decode JSON failed"},
+		{name: "unreachable", respErr: errors.New("connection refused"), expErr: "connection refused"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := &ClientMock{DoFunc: func(req *http.Request) (*http.Response, error) {
+				if action := req.URL.Query().Get("action"); action != "getDomainList" {
+					t.Fatalf("unexpected action: %s", action)
+				}
+				if test.respErr != nil {
+					return nil, test.respErr
+				}
+				time.Sleep(time.Millisecond)
+				return &http.Response{StatusCode: test.respCode, Body: io.NopCloser(strings.NewReader(test.respBody))}, nil
+			}}
+			api := onesecmail.NewAPI(onesecmail.WithHTTPClient(client))
+			ctx := context.Background()
+
+			status, err := api.Status(ctx)
+			healthErr := api.HealthCheck(ctx)
+			reachable := api.IsReachable(ctx)
+			if test.expErr != "" {
+				for _, err := range []error{err, healthErr} {
+					if err == nil || !strings.Contains(err.Error(), test.expErr) {
+						t.Fatalf("error expected: %s, got: %v", test.expErr, err)
+					}
+				}
+				if reachable {
+					t.Fatal("should not be reachable")
+				}
+				return
+			}
+			if err != nil || healthErr != nil || !reachable {
+				t.Fatalf("should be healthy, got: %v, %v", err, healthErr)
+			}
+			if !reflect.DeepEqual(status.Domains, test.expDomains) {
+				t.Fatalf("domains expected: %v, got: %v", test.expDomains, status.Domains)
+			}
+			if status.Latency < time.Millisecond {
+				t.Fatalf("latency should be measured, got: %v", status.Latency)
+			}
+		})
+	}
+}
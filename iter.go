@@ -0,0 +1,33 @@
+//go:build go1.23
+
+package onesecmail
+
+import (
+	"context"
+	"iter"
+)
+
+// Messages returns an iterator over the mails in the inbox of a mailbox, read in
+// full with ReadMessage one at a time as the iteration goes on, so no more mails are
+// read once the caller stops:
+//
+//	for mail, err := range mb.Messages(ctx) {
+//		...
+//	}
+//
+// If the inbox cannot be checked, the error is yielded once. If a mail cannot be
+// read, its error is yielded with a nil mail, and the iteration goes on.
+func (m Mailbox) Messages(ctx context.Context) iter.Seq2[*Mail, error] {
+	return func(yield func(*Mail, error) bool) {
+		mails, err := m.CheckInbox(ctx)
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		for _, summary := range mails {
+			if !yield(m.ReadMessage(ctx, summary.ID)) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,86 @@
+//go:build go1.23
+
+package onesecmail_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/z11i/onesecmail"
+)
+
+func Test_Messages(t *testing.T) {
+	inbox := `[{"id":1},{"id":2},{"id":3}]`
+	newMailbox := func(t *testing.T, inboxCode int, reads *[]string) onesecmail.Mailbox {
+		client := &ClientMock{DoFunc: func(req *http.Request) (*http.Response, error) {
+			query := req.URL.Query()
+			if query.Get("action") == "getMessages" {
+				return &http.Response{StatusCode: inboxCode, Body: io.NopCloser(strings.NewReader(inbox))}, nil
+			}
+			id := query.Get("id")
+			*reads = append(*reads, id)
+			if id == "2" {
+				return &http.Response{StatusCode: 404, Body: io.NopCloser(strings.NewReader("Message not found"))}, nil
+			}
+			body := `{"id":` + id + `,"textBody":"hi"}`
+			return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(body))}, nil
+		}}
+		mailbox, err := onesecmail.NewMailbox("foo", "1secmail.com", onesecmail.WithHTTPClient(client))
+		if err != nil {
+			t.Fatal("should not error")
+		}
+		return mailbox
+	}
+	ctx := context.Background()
+
+	t.Run("all mails", func(t *testing.T) {
+		var reads []string
+		var ids []int
+		var errs []string
+		for mail, err := range newMailbox(t, 200, &reads).Messages(ctx) {
+			if err != nil {
+				errs = append(errs, err.Error())
+				continue
+			}
+			if mail.TextBody == nil {
+				t.Fatal("full mail should be read")
+			}
+			ids = append(ids, mail.ID)
+		}
+		if !reflect.DeepEqual(ids, []int{1, 3}) {
+			t.Fatalf("mails 1 and 3 expected, got: %v", ids)
+		}
+		if len(errs) != 1 || !strings.Contains(errs[0], "read message failed") {
+			t.Fatalf("1 read error expected, got: %v", errs)
+		}
+	})
+	t.Run("break stops reading", func(t *testing.T) {
+		var reads []string
+		for mail, err := range newMailbox(t, 200, &reads).Messages(ctx) {
+			if err != nil || mail.ID != 1 {
+				t.Fatalf("mail 1 expected, got: %v, %v", mail, err)
+			}
+			break
+		}
+		if !reflect.DeepEqual(reads, []string{"1"}) {
+			t.Fatalf("only mail 1 should be read, got: %v", reads)
+		}
+	})
+	t.Run("check inbox fails", func(t *testing.T) {
+		var reads []string
+		n := 0
+		for mail, err := range newMailbox(t, 500, &reads).Messages(ctx) {
+			n++
+			if mail != nil || err == nil || !strings.Contains(err.Error(), "check inbox failed") {
+				t.Fatalf("check inbox error expected, got: %v, %v", mail, err)
+			}
+		}
+		if n != 1 || len(reads) != 0 {
+			t.Fatalf("1 error and no reads expected, got: %d, %v", n, reads)
+		}
+	})
+}
@@ -0,0 +1,55 @@
+package onesecmail
+
+import (
+	"regexp"
+	"strings"
+)
+
+// HasKeyword reports whether the body of a mail contains keyword, ignoring case.
+// The TextBody, HTMLBody and Body are searched, with HTML stripped of its tags and
+// its entities decoded. It returns false if no body is set.
+func (m *Mail) HasKeyword(keyword string) bool {
+	keyword = strings.ToLower(keyword)
+	for _, body := range m.textBodies() {
+		if strings.Contains(strings.ToLower(body), keyword) {
+			return true
+		}
+	}
+	return false
+}
+
+// HasKeywordRegexp is like HasKeyword, but reports whether the body of a mail
+// matches re. It returns false if re is nil.
+func (m *Mail) HasKeywordRegexp(re *regexp.Regexp) bool {
+	if re == nil {
+		return false
+	}
+	for _, body := range m.textBodies() {
+		if re.MatchString(body) {
+			return true
+		}
+	}
+	return false
+}
+
+// MatchesAll reports whether the body of a mail contains all keywords, as in
+// HasKeyword. It returns true if there are no keywords.
+func (m *Mail) MatchesAll(keywords []string) bool {
+	for _, keyword := range keywords {
+		if !m.HasKeyword(keyword) {
+			return false
+		}
+	}
+	return true
+}
+
+// MatchesAny reports whether the body of a mail contains any of keywords, as in
+// HasKeyword. It returns false if there are no keywords.
+func (m *Mail) MatchesAny(keywords []string) bool {
+	for _, keyword := range keywords {
+		if m.HasKeyword(keyword) {
+			return true
+		}
+	}
+	return false
+}
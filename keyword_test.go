@@ -0,0 +1,75 @@
+package onesecmail_test
+
+import (
+	"regexp"
+	"testing"
+
+	"github.com/z11i/onesecmail"
+)
+
+func Test_HasKeyword(t *testing.T) {
+	tests := []struct {
+		name    string
+		mail    onesecmail.Mail
+		keyword string
+		exp     bool
+	}{
+		{name: "nil bodies", mail: onesecmail.Mail{}, keyword: "hello"},
+		{name: "text body", mail: onesecmail.Mail{TextBody: strPtr("Hello World")}, keyword: "hello", exp: true},
+		{name: "html body", mail: onesecmail.Mail{HTMLBody: strPtr("<p>Your <b>Invoice</b></p>")}, keyword: "your invoice", exp: true},
+		{name: "html tags are not searched", mail: onesecmail.Mail{HTMLBody: strPtr(`<p class="invoice">Hi</p>`)}, keyword: "invoice"},
+		{name: "html entities", mail: onesecmail.Mail{HTMLBody: strPtr("<p>Fish &amp; Chips</p>")}, keyword: "fish & chips", exp: true},
+		{name: "body only", mail: onesecmail.Mail{Body: strPtr("<div>Welcome aboard</div>")}, keyword: "ABOARD", exp: true},
+		{name: "unicode", mail: onesecmail.Mail{TextBody: strPtr("Ihre BESTÄTIGUNG für Zürich")}, keyword: "bestätigung", exp: true},
+		{name: "unicode html entity", mail: onesecmail.Mail{HTMLBody: strPtr("<p>Caf&eacute; ☕</p>")}, keyword: "café ☕", exp: true},
+		{name: "missing", mail: onesecmail.Mail{TextBody: strPtr("Hello"), HTMLBody: strPtr("<p>World</p>")}, keyword: "bye"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.mail.HasKeyword(test.keyword); got != test.exp {
+				t.Fatalf("expected: %v, got: %v", test.exp, got)
+			}
+		})
+	}
+}
+
+func Test_HasKeywordRegexp(t *testing.T) {
+	mail := onesecmail.Mail{HTMLBody: strPtr("<p>Order <b>#A-1234</b> shipped</p>")}
+	if !mail.HasKeywordRegexp(regexp.MustCompile(`#[A-Z]-\d{4}`)) {
+		t.Fatal("pattern should match")
+	}
+	if mail.HasKeywordRegexp(regexp.MustCompile(`<b>`)) {
+		t.Fatal("pattern should not match HTML tags")
+	}
+	if mail.HasKeywordRegexp(nil) {
+		t.Fatal("nil pattern should not match")
+	}
+	if (&onesecmail.Mail{}).HasKeywordRegexp(regexp.MustCompile(`.*`)) {
+		t.Fatal("mail without bodies should not match")
+	}
+}
+
+func Test_MatchesAllAny(t *testing.T) {
+	mail := onesecmail.Mail{TextBody: strPtr("Your password reset code"), HTMLBody: strPtr("<p>Expires in 10 minutes</p>")}
+	tests := []struct {
+		name     string
+		keywords []string
+		expAll   bool
+		expAny   bool
+	}{
+		{name: "all present", keywords: []string{"password", "EXPIRES"}, expAll: true, expAny: true},
+		{name: "some present", keywords: []string{"password", "invoice"}, expAny: true},
+		{name: "none present", keywords: []string{"invoice", "receipt"}},
+		{name: "no keywords", keywords: nil, expAll: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := mail.MatchesAll(test.keywords); got != test.expAll {
+				t.Fatalf("MatchesAll expected: %v, got: %v", test.expAll, got)
+			}
+			if got := mail.MatchesAny(test.keywords); got != test.expAny {
+				t.Fatalf("MatchesAny expected: %v, got: %v", test.expAny, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,152 @@
+package onesecmail
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/template"
+	"time"
+	"unicode"
+)
+
+// dateLayout is the layout of the dates returned by 1secmail.
+const dateLayout = "2006-01-02 15:04:05"
+
+// ParseDate parses the Date of a mail, which 1secmail reports in UTC.
+func (m *Mail) ParseDate() (time.Time, error) {
+	t, err := time.ParseInLocation(dateLayout, m.Date, time.UTC)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("parse date failed: %w", err)
+	}
+	return t, nil
+}
+
+// UnmarshalJSON decodes a mail from JSON, and sets its ParsedDate. A Date that
+// cannot be parsed leaves ParsedDate zero, and is not an error.
+func (m *Mail) UnmarshalJSON(data []byte) error {
+	type mail Mail
+	if err := json.Unmarshal(data, (*mail)(m)); err != nil {
+		return err
+	}
+	m.ParsedDate, _ = m.ParseDate()
+	return nil
+}
+
+// date returns the ParsedDate of the mail, or its Date if ParsedDate is zero, and
+// reports whether either is set.
+func (m *Mail) date() (time.Time, bool) {
+	if !m.ParsedDate.IsZero() {
+		return m.ParsedDate, true
+	}
+	date, err := m.ParseDate()
+	return date, err == nil
+}
+
+// Age returns how long ago the mail was received, according to its ParsedDate, or
+// its Date if ParsedDate is zero. It returns an error if neither is set.
+func (m *Mail) Age() (time.Duration, error) {
+	date := m.ParsedDate
+	if date.IsZero() {
+		var err error
+		if date, err = m.ParseDate(); err != nil {
+			return 0, err
+		}
+	}
+	return time.Since(date), nil
+}
+
+// IsOlderThan reports whether the mail was received more than d ago. It returns
+// false if the age of the mail is unknown.
+func (m *Mail) IsOlderThan(d time.Duration) bool {
+	age, err := m.Age()
+	return err == nil && age > d
+}
+
+// IsFromDomain reports whether the address of the sender of the mail is at domain,
+// ignoring case. The sender may include a display name, as in
+// "Sender <user@example.com>".
+func (m *Mail) IsFromDomain(domain string) bool {
+	address := senderAddress(m.From)
+	at := strings.LastIndex(address, "@")
+	return at >= 0 && domain != "" && strings.EqualFold(address[at+1:], domain)
+}
+
+// String returns a one line summary of the mail for logging, with its ID, subject,
+// sender, date and number of attachments.
+func (m *Mail) String() string {
+	if m == nil {
+		return "<nil>"
+	}
+	attachments := fmt.Sprintf("%d attachments", len(m.Attachments))
+	if len(m.Attachments) == 1 {
+		attachments = "1 attachment"
+	}
+	return fmt.Sprintf("#%d %q from %q at %q, %s", m.ID, m.Subject, m.From, m.Date, attachments)
+}
+
+// Summary returns a one line summary of the mail in key=value form, such as
+//
+//	ID=639 from=someone@example.com subject="Some subject" date=2018-06-08T14:33:55Z attachments=2
+//
+// The date is formatted as RFC 3339, or "unknown" if it cannot be parsed, and
+// non-printable characters in the subject are replaced with '?'.
+func (m *Mail) Summary() string {
+	date := "unknown"
+	if d, ok := m.date(); ok {
+		date = d.Format(time.RFC3339)
+	}
+	subject := strings.Map(func(r rune) rune {
+		if !unicode.IsPrint(r) {
+			return '?'
+		}
+		return r
+	}, m.Subject)
+	return fmt.Sprintf("ID=%d from=%s subject=%q date=%s attachments=%d",
+		m.ID, senderAddress(m.From), subject, date, len(m.Attachments))
+}
+
+// Format formats the mail with the text/template tmpl, executed with the mail as its
+// data, e.g. "{{.ID}}: {{.Subject}}".
+func (m *Mail) Format(tmpl string) (string, error) {
+	t, err := template.New("mail").Parse(tmpl)
+	if err != nil {
+		return "", fmt.Errorf("format mail failed: %w", err)
+	}
+	var b strings.Builder
+	if err := t.Execute(&b, m); err != nil {
+		return "", fmt.Errorf("format mail failed: %w", err)
+	}
+	return b.String(), nil
+}
+
+// Equal reports whether m and other are both nil, or have the same fields and
+// attachments. Bodies are compared by value, and ParsedDate, which is derived from
+// Date, is ignored. An attachment whose Content has not been downloaded is not
+// equal to an empty one.
+func (m *Mail) Equal(other *Mail) bool {
+	if m == nil || other == nil {
+		return m == other
+	}
+	if m.ID != other.ID || m.From != other.From || m.Subject != other.Subject || m.Date != other.Date ||
+		!equalBody(m.Body, other.Body) || !equalBody(m.TextBody, other.TextBody) || !equalBody(m.HTMLBody, other.HTMLBody) ||
+		len(m.Attachments) != len(other.Attachments) {
+		return false
+	}
+	for i, a := range m.Attachments {
+		b := other.Attachments[i]
+		if a.Filename != b.Filename || a.ContentType != b.ContentType || a.Size != b.Size ||
+			(a.Content == nil) != (b.Content == nil) || !bytes.Equal(a.Content, b.Content) {
+			return false
+		}
+	}
+	return true
+}
+
+// equalBody reports whether a and b are both nil, or point to the same body.
+func equalBody(a, b *string) bool {
+	if a == nil || b == nil {
+		return a == b
+	}
+	return *a == *b
+}
@@ -0,0 +1,344 @@
+package onesecmail_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/z11i/onesecmail"
+)
+
+func Test_ParseDate(t *testing.T) {
+	tests := []struct {
+		name    string
+		date    string
+		expDate time.Time
+		expErr  bool
+	}{
+		{name: "valid date", date: "2018-06-08 14:33:55", expDate: time.Date(2018, 6, 8, 14, 33, 55, 0, time.UTC)},
+		{name: "empty date", date: "", expErr: true},
+		{name: "malformed date", date: "2018-06-08T14:33:55Z", expErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mail := &onesecmail.Mail{Date: test.date}
+			got, err := mail.ParseDate()
+			if (err == nil) != !test.expErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if !got.Equal(test.expDate) {
+				t.Fatalf("date expected: %v, got: %v", test.expDate, got)
+			}
+		})
+	}
+}
+
+func Test_Mail_JSONRoundTrip(t *testing.T) {
+	tests := []struct {
+		name string
+		mail onesecmail.Mail
+	}{
+		{name: "inbox summary", mail: onesecmail.Mail{ID: 639, From: "someone@example.com", Subject: "Some subject", Date: "2018-06-08 14:33:55"}},
+		{
+			name: "full mail",
+			mail: onesecmail.Mail{
+				ID: 639, From: "someone@example.com", Subject: "Some subject", Date: "2018-06-08 14:33:55",
+				Attachments: []onesecmail.Attachment{{Filename: "a.pdf", ContentType: "application/pdf", Size: 1024}},
+				Body:        strPtr("<p>hi</p>"), TextBody: strPtr("hi"), HTMLBody: strPtr("<p>hi</p>"),
+			},
+		},
+		{name: "empty bodies", mail: onesecmail.Mail{ID: 1, Body: strPtr(""), TextBody: strPtr(""), HTMLBody: strPtr("")}},
+		{name: "empty attachments", mail: onesecmail.Mail{ID: 1, Attachments: []onesecmail.Attachment{}}},
+		{
+			name: "attachment content",
+			mail: onesecmail.Mail{ID: 1, Attachments: []onesecmail.Attachment{
+				{Filename: "a.pdf", Size: 3, Content: []byte("pdf")},
+				{Filename: "empty.txt", Content: []byte{}},
+				{Filename: "b.pdf", Size: 1024},
+			}},
+		},
+		{name: "unparseable date", mail: onesecmail.Mail{ID: 1, Date: "not a date"}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			data, err := json.Marshal(&test.mail)
+			if err != nil {
+				t.Fatalf("should not error: %v", err)
+			}
+			var got onesecmail.Mail
+			if err := json.Unmarshal(data, &got); err != nil {
+				t.Fatalf("should not error: %v", err)
+			}
+			exp := test.mail
+			exp.ParsedDate, _ = exp.ParseDate()
+			if !reflect.DeepEqual(got, exp) {
+				t.Fatalf("round trip expected: %+v, got: %+v", exp, got)
+			}
+		})
+	}
+}
+
+func Test_Mail_UnmarshalJSON_ParsedDate(t *testing.T) {
+	tests := []struct {
+		name    string
+		data    string
+		expDate time.Time
+		expRaw  string
+	}{
+		{
+			name:    "valid date",
+			data:    `{"id":639,"date":"2018-06-08 14:33:55"}`,
+			expDate: time.Date(2018, 6, 8, 14, 33, 55, 0, time.UTC),
+			expRaw:  "2018-06-08 14:33:55",
+		},
+		{name: "invalid date", data: `{"id":639,"date":"yesterday"}`, expRaw: "yesterday"},
+		{name: "missing date", data: `{"id":639}`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var mails []*onesecmail.Mail
+			if err := json.Unmarshal([]byte("["+test.data+"]"), &mails); err != nil {
+				t.Fatalf("should not error: %v", err)
+			}
+			mail := mails[0]
+			if mail.ID != 639 || mail.Date != test.expRaw {
+				t.Fatalf("fields should be decoded, got: %+v", mail)
+			}
+			if !mail.ParsedDate.Equal(test.expDate) {
+				t.Fatalf("parsed date expected: %v, got: %v", test.expDate, mail.ParsedDate)
+			}
+			// A zero ParsedDate is still usable in age calculations.
+			if age := time.Since(mail.ParsedDate); age <= 0 {
+				t.Fatalf("age should be positive, got: %v", age)
+			}
+		})
+	}
+}
+
+func Test_Mail_Age(t *testing.T) {
+	singapore := time.FixedZone("SGT", 8*60*60)
+	tests := []struct {
+		name      string
+		mail      onesecmail.Mail
+		expAge    time.Duration
+		expErr    bool
+		expOlder  bool
+		olderThan time.Duration
+	}{
+		{
+			name:      "parsed date",
+			mail:      onesecmail.Mail{ParsedDate: time.Now().UTC().Add(-2 * time.Hour)},
+			expAge:    2 * time.Hour,
+			olderThan: time.Hour,
+			expOlder:  true,
+		},
+		{
+			name:      "parsed date in another time zone",
+			mail:      onesecmail.Mail{ParsedDate: time.Now().In(singapore).Add(-30 * time.Minute)},
+			expAge:    30 * time.Minute,
+			olderThan: time.Hour,
+		},
+		{
+			name:      "date only",
+			mail:      onesecmail.Mail{Date: time.Now().UTC().Add(-3 * time.Hour).Format("2006-01-02 15:04:05")},
+			expAge:    3 * time.Hour,
+			olderThan: time.Hour,
+			expOlder:  true,
+		},
+		{name: "zero parsed date", mail: onesecmail.Mail{}, expErr: true},
+		{name: "unparseable date", mail: onesecmail.Mail{Date: "yesterday"}, expErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			age, err := test.mail.Age()
+			if (err == nil) != !test.expErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if diff := age - test.expAge; diff < -time.Second || diff > time.Second {
+				t.Fatalf("age expected: %v, got: %v", test.expAge, age)
+			}
+			if older := test.mail.IsOlderThan(test.olderThan); older != test.expOlder {
+				t.Fatalf("older than %v expected: %v, got: %v", test.olderThan, test.expOlder, older)
+			}
+		})
+	}
+}
+
+func Test_Mail_IsFromDomain(t *testing.T) {
+	tests := []struct {
+		name   string
+		from   string
+		domain string
+		exp    bool
+	}{
+		{name: "plain address", from: "user@example.com", domain: "example.com", exp: true},
+		{name: "display name", from: "Sender <user@example.com>", domain: "example.com", exp: true},
+		{name: "quoted display name with domain", from: `"user@example.com" <user@evil.com>`, domain: "example.com"},
+		{name: "different case", from: "user@Example.COM", domain: "example.com", exp: true},
+		{name: "subdomain", from: "user@mail.example.com", domain: "example.com"},
+		{name: "suffix", from: "user@notexample.com", domain: "example.com"},
+		{name: "no address", from: "Sender", domain: "example.com"},
+		{name: "empty domain", from: "user@", domain: ""},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mail := onesecmail.Mail{From: test.from}
+			if got := mail.IsFromDomain(test.domain); got != test.exp {
+				t.Fatalf("expected: %v, got: %v", test.exp, got)
+			}
+		})
+	}
+}
+
+func Test_Mail_String(t *testing.T) {
+	tests := []struct {
+		name string
+		mail *onesecmail.Mail
+		exp  string
+	}{
+		{
+			name: "summary",
+			mail: &onesecmail.Mail{ID: 639, From: "someone@example.com", Subject: "Some subject", Date: "2018-06-08 14:33:55",
+				Body: strPtr("body"), Attachments: []onesecmail.Attachment{{Filename: "a.pdf"}, {Filename: "b.pdf"}}},
+			exp: `#639 "Some subject" from "someone@example.com" at "2018-06-08 14:33:55", 2 attachments`,
+		},
+		{
+			name: "one attachment",
+			mail: &onesecmail.Mail{ID: 1, Attachments: []onesecmail.Attachment{{Filename: "a.pdf"}}},
+			exp:  `#1 "" from "" at "", 1 attachment`,
+		},
+		{
+			name: "multiline subject",
+			mail: &onesecmail.Mail{ID: 2, Subject: "Line one\nLine two", From: "Sender <user@example.com>"},
+			exp:  `#2 "Line one\nLine two" from "Sender <user@example.com>" at "", 0 attachments`,
+		},
+		{name: "nil mail", mail: nil, exp: "<nil>"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := fmt.Sprint(test.mail); got != test.exp {
+				t.Fatalf("expected: %s, got: %s", test.exp, got)
+			}
+		})
+	}
+}
+
+func Test_Mail_Summary(t *testing.T) {
+	tests := []struct {
+		name string
+		mail onesecmail.Mail
+		exp  string
+	}{
+		{
+			name: "full mail",
+			mail: onesecmail.Mail{ID: 639, From: "someone@example.com", Subject: "Some subject", Date: "2018-06-08 14:33:55",
+				Attachments: []onesecmail.Attachment{{Filename: "a.pdf"}, {Filename: "b.pdf"}}},
+			exp: `ID=639 from=someone@example.com subject="Some subject" date=2018-06-08T14:33:55Z attachments=2`,
+		},
+		{
+			name: "parsed date",
+			mail: onesecmail.Mail{ID: 1, ParsedDate: time.Date(2018, 6, 8, 14, 33, 55, 0, time.UTC)},
+			exp:  `ID=1 from= subject="" date=2018-06-08T14:33:55Z attachments=0`,
+		},
+		{
+			name: "display name and non-printable subject",
+			mail: onesecmail.Mail{ID: 2, From: "Sender <user@example.com>", Subject: "Hi\tthere\x00 \"you\" ☕", Date: "bad"},
+			exp:  `ID=2 from=user@example.com subject="Hi?there? \"you\" ☕" date=unknown attachments=0`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.mail.Summary(); got != test.exp {
+				t.Fatalf("expected: %s, got: %s", test.exp, got)
+			}
+		})
+	}
+}
+
+func Test_Mail_Format(t *testing.T) {
+	mail := onesecmail.Mail{ID: 639, From: "someone@example.com", Subject: "Some subject",
+		Attachments: []onesecmail.Attachment{{Filename: "a.pdf"}}}
+	tests := []struct {
+		name   string
+		tmpl   string
+		exp    string
+		expErr bool
+	}{
+		{name: "fields", tmpl: "{{.ID}}: {{.Subject}} <{{.From}}>", exp: "639: Some subject <someone@example.com>"},
+		{name: "methods", tmpl: "{{if .HasAttachments}}{{len .Attachments}} attached{{end}}", exp: "1 attached"},
+		{name: "invalid template", tmpl: "{{.ID", expErr: true},
+		{name: "unknown field", tmpl: "{{.Nope}}", expErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := mail.Format(test.tmpl)
+			if (err == nil) != !test.expErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if got != test.exp {
+				t.Fatalf("expected: %q, got: %q", test.exp, got)
+			}
+		})
+	}
+}
+
+func Test_Mail_Equal(t *testing.T) {
+	mail := func() *onesecmail.Mail {
+		return &onesecmail.Mail{
+			ID: 639, From: "someone@example.com", Subject: "Some subject", Date: "2018-06-08 14:33:55",
+			ParsedDate:  time.Date(2018, 6, 8, 14, 33, 55, 0, time.UTC),
+			Attachments: []onesecmail.Attachment{{Filename: "a.pdf", ContentType: "application/pdf", Size: 3, Content: []byte("pdf")}},
+			Body:        strPtr("<p>hi</p>"), TextBody: strPtr("hi"), HTMLBody: strPtr("<p>hi</p>"),
+		}
+	}
+	tests := []struct {
+		name   string
+		change func(m *onesecmail.Mail)
+		exp    bool
+	}{
+		{name: "same fields", change: func(m *onesecmail.Mail) {}, exp: true},
+		{name: "parsed date not set", change: func(m *onesecmail.Mail) { m.ParsedDate = time.Time{} }, exp: true},
+		{name: "different date", change: func(m *onesecmail.Mail) { m.Date = "2018-06-09 14:33:55" }},
+		{name: "different ID", change: func(m *onesecmail.Mail) { m.ID = 640 }},
+		{name: "different subject", change: func(m *onesecmail.Mail) { m.Subject = "Other subject" }},
+		{name: "different body", change: func(m *onesecmail.Mail) { m.TextBody = strPtr("bye") }},
+		{name: "missing body", change: func(m *onesecmail.Mail) { m.HTMLBody = nil }},
+		{name: "extra attachment", change: func(m *onesecmail.Mail) {
+			m.Attachments = append(m.Attachments, onesecmail.Attachment{Filename: "b.pdf"})
+		}},
+		{name: "different attachment content", change: func(m *onesecmail.Mail) { m.Attachments[0].Content = []byte("PDF") }},
+		{name: "attachment not downloaded", change: func(m *onesecmail.Mail) { m.Attachments[0].Content = nil }},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			other := mail()
+			test.change(other)
+			if got := mail().Equal(other); got != test.exp {
+				t.Fatalf("expected: %v, got: %v", test.exp, got)
+			}
+			if got := other.Equal(mail()); got != test.exp {
+				t.Fatalf("expected symmetric: %v, got: %v", test.exp, got)
+			}
+		})
+	}
+
+	// A mail is equal to itself after a round trip through JSON, which sets ParsedDate.
+	data, err := json.Marshal(&onesecmail.Mail{ID: 1, Date: "2018-06-08 14:33:55"})
+	if err != nil {
+		t.Fatalf("should not error: %v", err)
+	}
+	var decoded onesecmail.Mail
+	if err := json.Unmarshal(data, &decoded); err != nil {
+		t.Fatalf("should not error: %v", err)
+	}
+	if !decoded.Equal(&onesecmail.Mail{ID: 1, Date: "2018-06-08 14:33:55"}) {
+		t.Fatalf("decoded mail should be equal, got: %+v", decoded)
+	}
+
+	var nilMail *onesecmail.Mail
+	if !nilMail.Equal(nil) || nilMail.Equal(mail()) || mail().Equal(nil) {
+		t.Fatal("nil mails should only be equal to each other")
+	}
+}
@@ -0,0 +1,208 @@
+package onesecmail
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io"
+	"mime"
+	"mime/multipart"
+	"mime/quotedprintable"
+	"net/mail"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// mboxFromRe matches the lines of a message that must be escaped in an mbox file.
+var mboxFromRe = regexp.MustCompile(`(?m)^(>*From )`)
+
+// ExportToMbox writes mails to w in the mboxrd format, so that they can be imported
+// into email clients. Each mail is written as by WriteEML, after a "From " line with
+// its sender and date, and lines of the message starting with "From " are escaped
+// with a ">". On error, w may have been partially written.
+func ExportToMbox(mails []*Mail, w io.Writer) error {
+	for _, m := range mails {
+		var buf bytes.Buffer
+		if err := m.WriteEML(&buf, ""); err != nil {
+			return err
+		}
+		sender := senderAddress(m.From)
+		if sender == "" || strings.ContainsAny(sender, " \t") {
+			sender = "MAILER-DAEMON"
+		}
+		date, err := m.ParseDate()
+		if err != nil {
+			date = time.Unix(0, 0).UTC()
+		}
+		msg := strings.ReplaceAll(buf.String(), "\r\n", "\n")
+		msg = mboxFromRe.ReplaceAllString(msg, ">$1")
+		if !strings.HasSuffix(msg, "\n") {
+			msg += "\n"
+		}
+		if _, err := fmt.Fprintf(w, "From %s %s\n%s\n", sender, date.Format(time.ANSIC), msg); err != nil {
+			return fmt.Errorf("export mbox failed: %w", err)
+		}
+	}
+	return nil
+}
+
+// ReadMbox reads the mails in an mbox file written by ExportToMbox. Mail IDs are not
+// kept in mbox files, so the IDs of the mails are zero, and the Content of empty
+// attachments is nil.
+func ReadMbox(r io.Reader) ([]*Mail, error) {
+	var (
+		mails []*Mail
+		msg   bytes.Buffer
+		inMsg bool
+	)
+	flush := func() error {
+		if !inMsg {
+			return nil
+		}
+		m, err := parseEML(bytes.TrimSuffix(msg.Bytes(), []byte("\n")))
+		if err != nil {
+			return fmt.Errorf("read mbox failed: message %d: %w", len(mails)+1, err)
+		}
+		mails = append(mails, m)
+		msg.Reset()
+		return nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, 1<<20)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "From ") {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+			inMsg = true
+			continue
+		}
+		if !inMsg {
+			return nil, fmt.Errorf("read mbox failed: missing From line")
+		}
+		if strings.HasPrefix(strings.TrimLeft(line, ">"), "From ") {
+			line = line[1:]
+		}
+		msg.WriteString(line)
+		msg.WriteString("\n")
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("read mbox failed: %w", err)
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return mails, nil
+}
+
+// parseEML parses an RFC 2822 message into a Mail.
+func parseEML(eml []byte) (*Mail, error) {
+	msg, err := mail.ReadMessage(bytes.NewReader(eml))
+	if err != nil {
+		return nil, err
+	}
+	decoder := new(mime.WordDecoder)
+	subject, err := decoder.DecodeHeader(msg.Header.Get("Subject"))
+	if err != nil {
+		return nil, err
+	}
+	from, err := decoder.DecodeHeader(msg.Header.Get("From"))
+	if err != nil {
+		return nil, err
+	}
+	m := &Mail{From: from, Subject: subject}
+	if date, err := msg.Header.Date(); err == nil {
+		m.Date = date.UTC().Format(dateLayout)
+		m.ParsedDate = date.UTC()
+	}
+
+	contentType := msg.Header.Get("Content-Type")
+	if contentType == "" {
+		contentType = "text/plain"
+	}
+	body := decodeTransfer(msg.Body, msg.Header.Get("Content-Transfer-Encoding"))
+	if err := m.readEntity(contentType, "", body); err != nil {
+		return nil, err
+	}
+	if m.HTMLBody != nil {
+		m.Body = m.HTMLBody
+	} else {
+		m.Body = m.TextBody
+	}
+	return m, nil
+}
+
+// readEntity reads the bodies and attachments of a MIME entity into m.
+func (m *Mail) readEntity(contentType, disposition string, body io.Reader) error {
+	mediaType, params, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		return err
+	}
+	if strings.HasPrefix(mediaType, "multipart/") {
+		mr := multipart.NewReader(body, params["boundary"])
+		for {
+			p, err := mr.NextPart()
+			if err == io.EOF {
+				return nil
+			}
+			if err != nil {
+				return err
+			}
+			partType := p.Header.Get("Content-Type")
+			if partType == "" {
+				partType = "text/plain"
+			}
+			partBody := decodeTransfer(p, p.Header.Get("Content-Transfer-Encoding"))
+			if err := m.readEntity(partType, p.Header.Get("Content-Disposition"), partBody); err != nil {
+				return err
+			}
+		}
+	}
+
+	content, err := io.ReadAll(body)
+	if err != nil {
+		return err
+	}
+	if dispositionType, dispositionParams, err := mime.ParseMediaType(disposition); err == nil && dispositionType == "attachment" {
+		size := len(content)
+		if n, err := strconv.Atoi(dispositionParams["size"]); err == nil && size == 0 {
+			size = n
+		}
+		m.Attachments = append(m.Attachments, Attachment{
+			Filename:    dispositionParams["filename"],
+			ContentType: mediaType,
+			Size:        size,
+		})
+		if len(content) > 0 {
+			m.Attachments[len(m.Attachments)-1].Content = content
+		}
+		return nil
+	}
+	if len(content) == 0 {
+		return nil
+	}
+	s := strings.ReplaceAll(string(content), "\r\n", "\n")
+	switch {
+	case mediaType == "text/html" && m.HTMLBody == nil:
+		m.HTMLBody = &s
+	case mediaType == "text/plain" && m.TextBody == nil:
+		m.TextBody = &s
+	}
+	return nil
+}
+
+// decodeTransfer returns a reader decoding body in the Content-Transfer-Encoding cte.
+func decodeTransfer(body io.Reader, cte string) io.Reader {
+	switch strings.ToLower(cte) {
+	case "quoted-printable":
+		return quotedprintable.NewReader(body)
+	case "base64":
+		return base64.NewDecoder(base64.StdEncoding, body)
+	}
+	return body
+}
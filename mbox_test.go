@@ -0,0 +1,88 @@
+package onesecmail_test
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/z11i/onesecmail"
+)
+
+type failingWriter struct{}
+
+func (failingWriter) Write(p []byte) (int, error) {
+	return 0, errors.New("disk full")
+}
+
+func Test_ExportToMbox(t *testing.T) {
+	text := "Hi,\nFrom now on, use this code:\n>From the team"
+	html := "<p>Hi</p>"
+	mails := []*onesecmail.Mail{
+		{
+			From:     "Someone <someone@example.com>",
+			Subject:  "Vérification",
+			Date:     "2018-06-08 14:33:55",
+			TextBody: &text,
+			HTMLBody: &html,
+			Attachments: []onesecmail.Attachment{
+				{Filename: "iometer.pdf", ContentType: "application/pdf", Size: 47412},
+				{Filename: "notes.txt", ContentType: "text/plain", Size: 5, Content: []byte("notes")},
+			},
+		},
+		{From: "Zoë <other@example.com>", Subject: "Plain", Date: "2018-06-08 14:40:55", TextBody: &text},
+	}
+
+	var buf bytes.Buffer
+	if err := onesecmail.ExportToMbox(mails, &buf); err != nil {
+		t.Fatalf("should not error: %v", err)
+	}
+	mbox := buf.String()
+	if !strings.HasPrefix(mbox, "From someone@example.com Fri Jun  8 14:33:55 2018\n") {
+		t.Fatalf("unexpected From line: %q", mbox[:strings.Index(mbox, "\n")])
+	}
+	if !strings.Contains(mbox, "\nFrom other@example.com Fri Jun  8 14:40:55 2018\n") {
+		t.Fatal("second message should have a From line")
+	}
+	if !strings.Contains(mbox, "\n>From now on") || !strings.Contains(mbox, "\n>>From the team") {
+		t.Fatal("From lines in the body should be escaped")
+	}
+
+	got, err := onesecmail.ReadMbox(&buf)
+	if err != nil {
+		t.Fatalf("should not error: %v", err)
+	}
+	if len(got) != len(mails) {
+		t.Fatalf("%d mails expected, got: %d", len(mails), len(got))
+	}
+	for i, exp := range mails {
+		exp := *exp
+		exp.ParsedDate, _ = exp.ParseDate()
+		if exp.HTMLBody != nil {
+			exp.Body = exp.HTMLBody
+		} else {
+			exp.Body = exp.TextBody
+		}
+		if !reflect.DeepEqual(*got[i], exp) {
+			t.Fatalf("mail %d expected: %+v, got: %+v", i, exp, *got[i])
+		}
+	}
+}
+
+func Test_ExportToMbox_WriteError(t *testing.T) {
+	mails := []*onesecmail.Mail{{From: "someone@example.com"}}
+	if err := onesecmail.ExportToMbox(mails, failingWriter{}); err == nil || !strings.Contains(err.Error(), "disk full") {
+		t.Fatalf("write error expected, got: %v", err)
+	}
+}
+
+func Test_ReadMbox_Invalid(t *testing.T) {
+	if _, err := onesecmail.ReadMbox(strings.NewReader("Subject: no separator\n\nbody\n")); err == nil {
+		t.Fatal("should error")
+	}
+	mails, err := onesecmail.ReadMbox(strings.NewReader(""))
+	if err != nil || len(mails) != 0 {
+		t.Fatalf("no mails expected, got: %v, %v", mails, err)
+	}
+}
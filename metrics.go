@@ -0,0 +1,35 @@
+package onesecmail
+
+import "time"
+
+// MetricsCollector collects metrics of the requests made to the API, e.g. to export
+// them to a metrics backend. The metrics subpackage provides one for Prometheus.
+type MetricsCollector interface {
+	// RecordRequestDuration is called after each request with its action, the status
+	// code of the response, or 0 if the request failed, and how long it took.
+	RecordRequestDuration(action string, statusCode int, d time.Duration)
+	// IncrementErrorCount is called after each request that failed, or whose response
+	// has a status code other than 200.
+	IncrementErrorCount(action string)
+	// RecordRetryAttempt is called before a request is retried, with the number of the
+	// attempt that failed, starting at 1.
+	RecordRetryAttempt(action string, attempt int)
+}
+
+// NoopMetricsCollector is a MetricsCollector that discards all metrics. It is used
+// if none is set with WithMetrics.
+type NoopMetricsCollector struct{}
+
+func (NoopMetricsCollector) RecordRequestDuration(action string, statusCode int, d time.Duration) {}
+
+func (NoopMetricsCollector) IncrementErrorCount(action string) {}
+
+func (NoopMetricsCollector) RecordRetryAttempt(action string, attempt int) {}
+
+// WithMetrics sets the MetricsCollector of the requests made to the API. If it is
+// not set, or mc is nil, NoopMetricsCollector is used.
+func WithMetrics(mc MetricsCollector) Option {
+	return func(cfg *config) {
+		cfg.metrics = mc
+	}
+}
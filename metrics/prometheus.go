@@ -0,0 +1,80 @@
+// Package metrics provides a onesecmail.MetricsCollector that exports the metrics of
+// the requests made to the 1secmail API to Prometheus.
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/z11i/onesecmail"
+)
+
+// PrometheusMetricsCollector is a onesecmail.MetricsCollector that records metrics
+// in Prometheus collectors:
+//
+//   - onesecmail_request_duration_seconds, a histogram of the durations of requests
+//     by action and status code;
+//   - onesecmail_errors_total, a counter of failed requests by action;
+//   - onesecmail_retries_total, a counter of retried requests by action and attempt.
+type PrometheusMetricsCollector struct {
+	duration *prometheus.HistogramVec
+	errors   *prometheus.CounterVec
+	retries  *prometheus.CounterVec
+}
+
+var _ onesecmail.MetricsCollector = (*PrometheusMetricsCollector)(nil)
+
+// NewPrometheusMetricsCollector returns a new PrometheusMetricsCollector, and
+// registers its collectors with reg. If reg is nil, prometheus.DefaultRegisterer is
+// used. An error is returned if they cannot be registered, e.g. because they have
+// already been registered with reg, and none of them are left registered.
+func NewPrometheusMetricsCollector(reg prometheus.Registerer) (*PrometheusMetricsCollector, error) {
+	if reg == nil {
+		reg = prometheus.DefaultRegisterer
+	}
+	c := &PrometheusMetricsCollector{
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Namespace: "onesecmail",
+			Name:      "request_duration_seconds",
+			Help:      "Duration of requests to the 1secmail API.",
+			Buckets:   prometheus.DefBuckets,
+		}, []string{"action", "status"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "onesecmail",
+			Name:      "errors_total",
+			Help:      "Requests to the 1secmail API that failed or returned a status code other than 200.",
+		}, []string{"action"}),
+		retries: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Namespace: "onesecmail",
+			Name:      "retries_total",
+			Help:      "Retries of requests to the 1secmail API.",
+		}, []string{"action", "attempt"}),
+	}
+	collectors := []prometheus.Collector{c.duration, c.errors, c.retries}
+	for i, collector := range collectors {
+		if err := reg.Register(collector); err != nil {
+			for _, registered := range collectors[:i] {
+				reg.Unregister(registered)
+			}
+			return nil, err
+		}
+	}
+	return c, nil
+}
+
+// RecordRequestDuration observes d in onesecmail_request_duration_seconds.
+func (c *PrometheusMetricsCollector) RecordRequestDuration(action string, statusCode int, d time.Duration) {
+	c.duration.WithLabelValues(action, strconv.Itoa(statusCode)).Observe(d.Seconds())
+}
+
+// IncrementErrorCount increments onesecmail_errors_total.
+func (c *PrometheusMetricsCollector) IncrementErrorCount(action string) {
+	c.errors.WithLabelValues(action).Inc()
+}
+
+// RecordRetryAttempt increments onesecmail_retries_total.
+func (c *PrometheusMetricsCollector) RecordRetryAttempt(action string, attempt int) {
+	c.retries.WithLabelValues(action, strconv.Itoa(attempt)).Inc()
+}
@@ -0,0 +1,88 @@
+package metrics_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+
+	"github.com/z11i/onesecmail"
+	"github.com/z11i/onesecmail/metrics"
+)
+
+type clientFunc func(req *http.Request) (*http.Response, error)
+
+func (f clientFunc) Do(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func Test_PrometheusMetricsCollector(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	collector, err := metrics.NewPrometheusMetricsCollector(reg)
+	if err != nil {
+		t.Fatalf("should not error: %v", err)
+	}
+	if _, err := metrics.NewPrometheusMetricsCollector(reg); err == nil {
+		t.Fatal("registering twice should error")
+	}
+
+	calls := 0
+	client := clientFunc(func(req *http.Request) (*http.Response, error) {
+		calls++
+		if calls == 1 {
+			return nil, errors.New("connection reset")
+		}
+		return &http.Response{StatusCode: 500, Body: io.NopCloser(strings.NewReader(""))}, nil
+	})
+	api := onesecmail.NewAPI(onesecmail.WithHTTPClient(client), onesecmail.WithMetrics(collector),
+		onesecmail.WithRetry(onesecmail.RetryConfig{
+			MaxAttempts:          2,
+			RetryableStatusCodes: []int{503},
+			Sleep:                func(ctx context.Context, d time.Duration) error { return nil },
+		}))
+	if _, err := api.Domains(context.Background()); err == nil {
+		t.Fatal("should error")
+	}
+
+	exp := `
+# HELP onesecmail_errors_total Requests to the 1secmail API that failed or returned a status code other than 200.
+# TYPE onesecmail_errors_total counter
+onesecmail_errors_total{action="getDomainList"} 1
+# HELP onesecmail_retries_total Retries of requests to the 1secmail API.
+# TYPE onesecmail_retries_total counter
+onesecmail_retries_total{action="getDomainList",attempt="1"} 1
+`
+	if err := testutil.GatherAndCompare(reg, strings.NewReader(exp), "onesecmail_errors_total", "onesecmail_retries_total"); err != nil {
+		t.Fatal(err)
+	}
+	if n := testutil.CollectAndCount(reg, "onesecmail_request_duration_seconds"); n != 1 {
+		t.Fatalf("1 duration series expected, got: %d", n)
+	}
+}
+
+func Test_NewPrometheusMetricsCollector_RegisterFailed(t *testing.T) {
+	reg := prometheus.NewRegistry()
+	conflict := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: "onesecmail",
+		Name:      "retries_total",
+		Help:      "Retries of requests to the 1secmail API.",
+	}, []string{"action", "attempt"})
+	if err := reg.Register(conflict); err != nil {
+		t.Fatalf("should not error: %v", err)
+	}
+	if _, err := metrics.NewPrometheusMetricsCollector(reg); err == nil {
+		t.Fatal("registering an already registered collector should error")
+	}
+
+	// The collectors registered before the failure were unregistered.
+	reg.Unregister(conflict)
+	if _, err := metrics.NewPrometheusMetricsCollector(reg); err != nil {
+		t.Fatalf("should not error: %v", err)
+	}
+}
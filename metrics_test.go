@@ -0,0 +1,97 @@
+package onesecmail_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/z11i/onesecmail"
+)
+
+type recordingMetrics struct {
+	mu      sync.Mutex
+	records []string
+}
+
+func (m *recordingMetrics) record(format string, args ...interface{}) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.records = append(m.records, fmt.Sprintf(format, args...))
+}
+
+func (m *recordingMetrics) RecordRequestDuration(action string, statusCode int, d time.Duration) {
+	m.record("duration %s %d", action, statusCode)
+}
+
+func (m *recordingMetrics) IncrementErrorCount(action string) {
+	m.record("error %s", action)
+}
+
+func (m *recordingMetrics) RecordRetryAttempt(action string, attempt int) {
+	m.record("retry %s %d", action, attempt)
+}
+
+func Test_WithMetrics(t *testing.T) {
+	readCalls := 0
+	client := &ClientMock{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			switch req.URL.Query().Get("action") {
+			case "getMessages":
+				return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(`[]`))}, nil
+			case "readMessage":
+				readCalls++
+				if readCalls == 1 {
+					return &http.Response{StatusCode: 503, Body: io.NopCloser(strings.NewReader(``))}, nil
+				}
+				return &http.Response{StatusCode: 404, Body: io.NopCloser(strings.NewReader(``))}, nil
+			}
+			return nil, errors.New("network down")
+		},
+	}
+	metrics := &recordingMetrics{}
+	mailbox, err := onesecmail.NewMailbox("foo", "1secmail.com", onesecmail.WithHTTPClient(client),
+		onesecmail.WithMetrics(metrics), onesecmail.WithRetry(onesecmail.RetryConfig{
+			MaxAttempts:          2,
+			RetryableStatusCodes: []int{503},
+			Sleep:                func(ctx context.Context, d time.Duration) error { return nil },
+		}))
+	if err != nil {
+		t.Fatal("should not error")
+	}
+	ctx := context.Background()
+	mailbox.CheckInbox(ctx)
+	mailbox.ReadMessage(ctx, 1)
+	mailbox.Domains(ctx)
+
+	exp := []string{
+		"duration getMessages 200",
+		"retry readMessage 1",
+		"duration readMessage 404", "error readMessage",
+		"retry getDomainList 1",
+		"duration getDomainList 0", "error getDomainList",
+	}
+	if !reflect.DeepEqual(metrics.records, exp) {
+		t.Fatalf("metrics expected: %v, got: %v", exp, metrics.records)
+	}
+}
+
+func Test_WithMetrics_Default(t *testing.T) {
+	client := &ClientMock{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			return nil, errors.New("network down")
+		},
+	}
+	for _, opt := range []onesecmail.Option{nil, onesecmail.WithMetrics(nil), onesecmail.WithMetrics(onesecmail.NoopMetricsCollector{})} {
+		api := onesecmail.NewAPI(onesecmail.WithHTTPClient(client), opt)
+		if _, err := api.Domains(context.Background()); err == nil {
+			t.Fatal("should error")
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package onesecmail
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+)
+
+// MultiInbox checks the inboxes of several mailboxes at once.
+type MultiInbox struct {
+	mailboxes []Mailbox
+}
+
+// NewMultiInbox returns a MultiInbox of mailboxes.
+func NewMultiInbox(mailboxes ...Mailbox) MultiInbox {
+	return MultiInbox{mailboxes: mailboxes}
+}
+
+// CheckAll checks the inboxes of all mailboxes concurrently, and returns their mails
+// merged and sorted by date, oldest first. Mails with the same date, or a date that
+// cannot be parsed, are sorted by ID. If checking some of the inboxes fails, the
+// mails of the others are returned along with the failures joined into an error.
+func (mi MultiInbox) CheckAll(ctx context.Context) ([]*Mail, error) {
+	results := make([][]*Mail, len(mi.mailboxes))
+	errs := make([]error, len(mi.mailboxes))
+	var wg sync.WaitGroup
+	for i, mb := range mi.mailboxes {
+		wg.Add(1)
+		go func(i int, mb Mailbox) {
+			defer wg.Done()
+			mails, err := mb.CheckInbox(ctx)
+			if err != nil {
+				errs[i] = fmt.Errorf("%s: %w", mb.Address(), err)
+				return
+			}
+			results[i] = mails
+		}(i, mb)
+	}
+	wg.Wait()
+
+	var all []*Mail
+	for _, mails := range results {
+		all = append(all, mails...)
+	}
+	SortByDate(all, true)
+	return all, errors.Join(errs...)
+}
@@ -0,0 +1,49 @@
+package onesecmail_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/z11i/onesecmail"
+)
+
+func Test_MultiInbox_CheckAll(t *testing.T) {
+	inboxes := map[string]string{
+		"foo": `[{"id":3,"from":"a@example.com","subject":"a","date":"2018-06-08 14:00:00"},{"id":1,"from":"a@example.com","subject":"a","date":"2018-06-08 10:00:00"}]`,
+		"bar": `[{"id":2,"from":"b@example.com","subject":"b","date":"2018-06-08 12:00:00"}]`,
+	}
+	client := &ClientMock{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			body, ok := inboxes[req.URL.Query().Get("login")]
+			if !ok {
+				return &http.Response{StatusCode: 500, Body: ioutil.NopCloser(strings.NewReader(""))}, nil
+			}
+			return &http.Response{StatusCode: 200, Body: ioutil.NopCloser(strings.NewReader(body))}, nil
+		},
+	}
+	var mailboxes []onesecmail.Mailbox
+	for _, login := range []string{"foo", "bar", "broken"} {
+		mb, err := onesecmail.NewMailbox(login, "1secmail.org", onesecmail.WithHTTPClient(client))
+		if err != nil {
+			t.Fatal("should not error")
+		}
+		mailboxes = append(mailboxes, mb)
+	}
+
+	mails, err := onesecmail.NewMultiInbox(mailboxes...).CheckAll(context.Background())
+	if !errors.Is(err, onesecmail.ErrHTTPStatus) || !strings.Contains(err.Error(), "broken@1secmail.org") {
+		t.Fatalf("expected failure of broken mailbox, got: %v", err)
+	}
+	if len(mails) != 3 {
+		t.Fatalf("len expected: 3, got: %d", len(mails))
+	}
+	for i, mail := range mails {
+		if mail.ID != i+1 {
+			t.Fatalf("mails should be sorted by date, got ID %d at %d", mail.ID, i)
+		}
+	}
+}
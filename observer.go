@@ -0,0 +1,25 @@
+package onesecmail
+
+import "time"
+
+// Observer observes the requests made to the API, e.g. to record metrics.
+type Observer interface {
+	// ObserveRequest is called after each request with its action, the status code
+	// of the response, or 0 if the request failed, and how long the request took.
+	ObserveRequest(action string, statusCode int, duration time.Duration)
+}
+
+// WithObserver sets an Observer notified of each request. If it is not set, or o is
+// nil, requests are not observed.
+func WithObserver(o Observer) Option {
+	return func(cfg *config) {
+		cfg.observer = o
+	}
+}
+
+// observe notifies the observer of a, if any, of a request.
+func (a API) observe(action string, statusCode int, duration time.Duration) {
+	if a.cfg.observer != nil {
+		a.cfg.observer.ObserveRequest(action, statusCode, duration)
+	}
+}
@@ -0,0 +1,60 @@
+package onesecmail_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/z11i/onesecmail"
+)
+
+type observation struct {
+	action     string
+	statusCode int
+}
+
+type recordingObserver struct {
+	mu           sync.Mutex
+	observations []observation
+}
+
+func (o *recordingObserver) ObserveRequest(action string, statusCode int, duration time.Duration) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+	o.observations = append(o.observations, observation{action, statusCode})
+}
+
+func Test_WithObserver(t *testing.T) {
+	client := &ClientMock{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			switch req.URL.Query().Get("action") {
+			case "getMessages":
+				return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(`[]`))}, nil
+			case "readMessage":
+				return &http.Response{StatusCode: 404, Body: io.NopCloser(strings.NewReader(``))}, nil
+			}
+			return nil, errors.New("network down")
+		},
+	}
+	observer := &recordingObserver{}
+	mailbox, err := onesecmail.NewMailbox("foo", "1secmail.com",
+		onesecmail.WithHTTPClient(client), onesecmail.WithObserver(observer))
+	if err != nil {
+		t.Fatal("should not error")
+	}
+	ctx := context.Background()
+	mailbox.CheckInbox(ctx)
+	mailbox.ReadMessage(ctx, 1)
+	mailbox.Domains(ctx)
+
+	exp := []observation{{"getMessages", 200}, {"readMessage", 404}, {"getDomainList", 0}}
+	if !reflect.DeepEqual(observer.observations, exp) {
+		t.Fatalf("observations expected: %v, got: %v", exp, observer.observations)
+	}
+}
@@ -0,0 +1,65 @@
+// Package onesecmailtest provides utilities for testing code that uses onesecmail.
+package onesecmailtest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+
+	"github.com/z11i/onesecmail"
+)
+
+// FakeMailbox is an in-memory mailbox with the same methods as onesecmail.Mailbox
+// for reading mails, so that code depending on them can be tested without making
+// HTTP requests. The zero value is an empty mailbox ready to use. It is safe for
+// concurrent use.
+type FakeMailbox struct {
+	// CheckInboxErr, if set, is returned by CheckInbox.
+	CheckInboxErr error
+	// ReadMessageErr, if set, is returned by ReadMessage.
+	ReadMessageErr error
+
+	mu    sync.Mutex
+	mails []*onesecmail.Mail
+}
+
+// Enqueue adds a mail to the inbox.
+func (f *FakeMailbox) Enqueue(m *onesecmail.Mail) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.mails = append(f.mails, m)
+}
+
+// CheckInbox returns the mails added to the inbox, in the order they were added.
+func (f *FakeMailbox) CheckInbox(ctx context.Context) ([]*onesecmail.Mail, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.CheckInboxErr != nil {
+		return nil, f.CheckInboxErr
+	}
+	mails := make([]*onesecmail.Mail, len(f.mails))
+	copy(mails, f.mails)
+	return mails, nil
+}
+
+// ReadMessage returns the mail with messageID. If there is no such mail, an error
+// wrapping onesecmail.ErrMessageNotFound is returned.
+func (f *FakeMailbox) ReadMessage(ctx context.Context, messageID int) (*onesecmail.Mail, error) {
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if f.ReadMessageErr != nil {
+		return nil, f.ReadMessageErr
+	}
+	for _, mail := range f.mails {
+		if mail.ID == messageID {
+			return mail, nil
+		}
+	}
+	return nil, fmt.Errorf("read message failed: %w: %d", onesecmail.ErrMessageNotFound, messageID)
+}
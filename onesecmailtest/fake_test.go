@@ -0,0 +1,56 @@
+package onesecmailtest_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/z11i/onesecmail"
+	"github.com/z11i/onesecmail/onesecmailtest"
+)
+
+// inbox is the behavior shared by onesecmail.Mailbox and FakeMailbox.
+type inbox interface {
+	CheckInbox(ctx context.Context) ([]*onesecmail.Mail, error)
+	ReadMessage(ctx context.Context, messageID int) (*onesecmail.Mail, error)
+}
+
+var (
+	_ inbox = onesecmail.Mailbox{}
+	_ inbox = &onesecmailtest.FakeMailbox{}
+)
+
+func Test_FakeMailbox(t *testing.T) {
+	ctx := context.Background()
+	var fake onesecmailtest.FakeMailbox
+
+	mails, err := fake.CheckInbox(ctx)
+	if err != nil || len(mails) != 0 {
+		t.Fatalf("empty inbox expected, got: %v, %v", mails, err)
+	}
+
+	fake.Enqueue(&onesecmail.Mail{ID: 639, Subject: "first"})
+	fake.Enqueue(&onesecmail.Mail{ID: 640, Subject: "second"})
+	mails, err = fake.CheckInbox(ctx)
+	if err != nil || len(mails) != 2 || mails[0].ID != 639 || mails[1].ID != 640 {
+		t.Fatalf("enqueued mails expected, got: %v, %v", mails, err)
+	}
+
+	mail, err := fake.ReadMessage(ctx, 640)
+	if err != nil || mail.Subject != "second" {
+		t.Fatalf("mail 640 expected, got: %v, %v", mail, err)
+	}
+	if _, err := fake.ReadMessage(ctx, 1); !errors.Is(err, onesecmail.ErrMessageNotFound) {
+		t.Fatalf("expected ErrMessageNotFound, got: %v", err)
+	}
+
+	injected := errors.New("injected")
+	fake.CheckInboxErr = injected
+	fake.ReadMessageErr = injected
+	if _, err := fake.CheckInbox(ctx); !errors.Is(err, injected) {
+		t.Fatalf("expected injected error, got: %v", err)
+	}
+	if _, err := fake.ReadMessage(ctx, 639); !errors.Is(err, injected) {
+		t.Fatalf("expected injected error, got: %v", err)
+	}
+}
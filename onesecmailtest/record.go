@@ -0,0 +1,141 @@
+package onesecmailtest
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sync"
+
+	"github.com/z11i/onesecmail"
+)
+
+// Interaction is a request and its response, as recorded by RecordingHTTPClient.
+type Interaction struct {
+	Method     string `json:"method"`
+	URL        string `json:"url"`
+	StatusCode int    `json:"statusCode"`
+	Body       string `json:"body"`
+}
+
+// RecordingHTTPClient is an onesecmail.HTTPClient that sends requests with another
+// HTTPClient, and records each request and its response. It is safe for concurrent use.
+type RecordingHTTPClient struct {
+	client   onesecmail.HTTPClient
+	filename string
+
+	mu           sync.Mutex
+	interactions []Interaction
+}
+
+// NewRecordingHTTPClient returns a RecordingHTTPClient that sends requests with
+// client. If filename is not empty, the interactions recorded so far are written
+// to it as JSON after each request, to be replayed by ReplayHTTPClient.
+func NewRecordingHTTPClient(client onesecmail.HTTPClient, filename string) *RecordingHTTPClient {
+	return &RecordingHTTPClient{client: client, filename: filename}
+}
+
+// Do sends req and records the response. Requests that fail without a response
+// are not recorded.
+func (r *RecordingHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, fmt.Errorf("record response failed: %w", err)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.interactions = append(r.interactions, Interaction{
+		Method:     req.Method,
+		URL:        req.URL.String(),
+		StatusCode: resp.StatusCode,
+		Body:       string(body),
+	})
+	if r.filename != "" {
+		if err := writeInteractions(r.filename, r.interactions); err != nil {
+			return nil, err
+		}
+	}
+	return resp, nil
+}
+
+// Interactions returns the interactions recorded so far.
+func (r *RecordingHTTPClient) Interactions() []Interaction {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]Interaction(nil), r.interactions...)
+}
+
+func writeInteractions(filename string, interactions []Interaction) error {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	enc.SetIndent("", "  ")
+	if err := enc.Encode(interactions); err != nil {
+		return fmt.Errorf("write interactions failed: %w", err)
+	}
+	if err := os.WriteFile(filename, buf.Bytes(), 0o644); err != nil {
+		return fmt.Errorf("write interactions failed: %w", err)
+	}
+	return nil
+}
+
+// ReplayHTTPClient is an onesecmail.HTTPClient that replays interactions recorded
+// by RecordingHTTPClient in order, without sending requests. It is safe for
+// concurrent use.
+type ReplayHTTPClient struct {
+	mu           sync.Mutex
+	interactions []Interaction
+	next         int
+}
+
+// NewReplayHTTPClient returns a ReplayHTTPClient for the interactions in filename.
+func NewReplayHTTPClient(filename string) (*ReplayHTTPClient, error) {
+	data, err := os.ReadFile(filename)
+	if err != nil {
+		return nil, fmt.Errorf("read interactions failed: %w", err)
+	}
+	var interactions []Interaction
+	if err := json.Unmarshal(data, &interactions); err != nil {
+		return nil, fmt.Errorf("read interactions failed: %w", err)
+	}
+	return &ReplayHTTPClient{interactions: interactions}, nil
+}
+
+// Do returns the response of the next recorded interaction. It returns an error if
+// all interactions have been replayed, or if req does not have the method and URL
+// of the next interaction.
+func (r *ReplayHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.next >= len(r.interactions) {
+		return nil, fmt.Errorf("replay failed: no interaction left for %s %s", req.Method, req.URL)
+	}
+	in := r.interactions[r.next]
+	if in.Method != req.Method || in.URL != req.URL.String() {
+		return nil, fmt.Errorf("replay failed: expected %s %s, got %s %s", in.Method, in.URL, req.Method, req.URL)
+	}
+	r.next++
+	return &http.Response{
+		Status:     fmt.Sprintf("%d %s", in.StatusCode, http.StatusText(in.StatusCode)),
+		StatusCode: in.StatusCode,
+		Header:     make(http.Header),
+		Body:       io.NopCloser(bytes.NewBufferString(in.Body)),
+		Request:    req,
+	}, nil
+}
+
+// Remaining returns the number of interactions not replayed yet.
+func (r *ReplayHTTPClient) Remaining() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.interactions) - r.next
+}
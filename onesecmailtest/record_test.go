@@ -0,0 +1,199 @@
+package onesecmailtest_test
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/z11i/onesecmail"
+	"github.com/z11i/onesecmail/onesecmailtest"
+)
+
+var update = flag.Bool("update", false, "update golden files in testdata")
+
+type staticClient struct {
+	code int
+	body string
+}
+
+func (c staticClient) Do(req *http.Request) (*http.Response, error) {
+	return &http.Response{StatusCode: c.code, Body: io.NopCloser(strings.NewReader(c.body))}, nil
+}
+
+// goldenScenarios are replayed from testdata. With -update, they are recorded again
+// from the responses in the table.
+var goldenScenarios = []struct {
+	name   string
+	resp   staticClient
+	call   func(ctx context.Context, mb onesecmail.Mailbox) error
+	expErr string
+}{
+	{
+		name: "check_inbox",
+		resp: staticClient{200, `[{"id":639,"from":"someone@example.com","subject":"Some subject","date":"2018-06-08 14:33:55"},{"id":640,"from":"someoneelse@example.com","subject":"Other subject","date":"2018-06-08 14:40:55"}]`},
+		call: func(ctx context.Context, mb onesecmail.Mailbox) error {
+			mails, err := mb.CheckInbox(ctx)
+			if err == nil && len(mails) != 2 {
+				return errors.New("2 mails expected")
+			}
+			return err
+		},
+	},
+	{
+		name:   "check_inbox_invalid_json",
+		resp:   staticClient{200, `[{"id":639,"from":"someone@example.com","subject":"Some subject","date":"2018]`},
+		call:   func(ctx context.Context, mb onesecmail.Mailbox) error { _, err := mb.CheckInbox(ctx); return err },
+		expErr: "decode JSON failed",
+	},
+	{
+		name:   "check_inbox_server_error",
+		resp:   staticClient{500, ``},
+		call:   func(ctx context.Context, mb onesecmail.Mailbox) error { _, err := mb.CheckInbox(ctx); return err },
+		expErr: "check inbox failed",
+	},
+	{
+		name: "read_message",
+		resp: staticClient{200, `{"id":639,"from":"someone@example.com","subject":"Some subject","date":"2018-06-08 14:33:55","attachments":[{"filename":"iometer.pdf","contentType":"application/pdf","size":47412}],"body":"Some message body\n\n","textBody":"Some message body\n\n","htmlBody":""}`},
+		call: func(ctx context.Context, mb onesecmail.Mailbox) error {
+			mail, err := mb.ReadMessage(ctx, 639)
+			if err == nil && (mail.ID != 639 || len(mail.Attachments) != 1) {
+				return errors.New("mail 639 with an attachment expected")
+			}
+			return err
+		},
+	},
+	{
+		name:   "read_message_not_found",
+		resp:   staticClient{404, `Message not found`},
+		call:   func(ctx context.Context, mb onesecmail.Mailbox) error { _, err := mb.ReadMessage(ctx, 1); return err },
+		expErr: "read message failed",
+	},
+	{
+		name: "download_attachment",
+		resp: staticClient{200, `%PDF-1.4`},
+		call: func(ctx context.Context, mb onesecmail.Mailbox) error {
+			rc, err := mb.DownloadAttachment(ctx, 639, "iometer.pdf")
+			if err != nil {
+				return err
+			}
+			defer rc.Close()
+			content, err := io.ReadAll(rc)
+			if err == nil && string(content) != "%PDF-1.4" {
+				return errors.New("attachment content expected")
+			}
+			return err
+		},
+	},
+	{
+		name: "random_addresses",
+		resp: staticClient{200, `["zwjx7z@qiott.com","uft4nu@qiott.com"]`},
+		call: func(ctx context.Context, mb onesecmail.Mailbox) error {
+			addresses, err := mb.RandomAddresses(ctx, 2)
+			if err == nil && len(addresses) != 2 {
+				return errors.New("2 addresses expected")
+			}
+			return err
+		},
+	},
+	{
+		name: "domains",
+		resp: staticClient{200, `["1secmail.com","1secmail.org","1secmail.net"]`},
+		call: func(ctx context.Context, mb onesecmail.Mailbox) error {
+			domains, err := mb.Domains(ctx)
+			if err == nil && len(domains) != 3 {
+				return errors.New("3 domains expected")
+			}
+			return err
+		},
+	},
+	{
+		name:   "domains_rate_limited",
+		resp:   staticClient{429, `Too Many Requests`},
+		call:   func(ctx context.Context, mb onesecmail.Mailbox) error { _, err := mb.Domains(ctx); return err },
+		expErr: "get domain list failed",
+	},
+}
+
+func Test_Golden(t *testing.T) {
+	for _, test := range goldenScenarios {
+		t.Run(test.name, func(t *testing.T) {
+			golden := filepath.Join("testdata", test.name+".json")
+			var client onesecmail.HTTPClient
+			if *update {
+				client = onesecmailtest.NewRecordingHTTPClient(test.resp, golden)
+			} else {
+				replay, err := onesecmailtest.NewReplayHTTPClient(golden)
+				if err != nil {
+					t.Fatalf("should not error: %v", err)
+				}
+				defer func() {
+					if n := replay.Remaining(); n != 0 {
+						t.Fatalf("all interactions should be replayed, %d left", n)
+					}
+				}()
+				client = replay
+			}
+			mailbox, err := onesecmail.NewMailbox("foo", "1secmail.com", onesecmail.WithHTTPClient(client))
+			if err != nil {
+				t.Fatalf("should not error: %v", err)
+			}
+			err = test.call(context.Background(), mailbox)
+			if (err == nil) != (test.expErr == "") {
+				t.Fatalf("error expected: %q, got: %v", test.expErr, err)
+			}
+			if err != nil && !strings.Contains(err.Error(), test.expErr) {
+				t.Fatalf("error expected: %s, got: %s", test.expErr, err.Error())
+			}
+		})
+	}
+}
+
+func Test_RecordingHTTPClient(t *testing.T) {
+	filename := filepath.Join(t.TempDir(), "interactions.json")
+	recorder := onesecmailtest.NewRecordingHTTPClient(staticClient{500, `oops`}, filename)
+	api := onesecmail.NewAPI(onesecmail.WithHTTPClient(recorder))
+	if _, err := api.Domains(context.Background()); err == nil {
+		t.Fatal("should error")
+	}
+
+	interactions := recorder.Interactions()
+	if len(interactions) != 1 {
+		t.Fatalf("1 interaction expected, got: %d", len(interactions))
+	}
+	if in := interactions[0]; in.Method != "GET" || in.StatusCode != 500 || in.Body != "oops" ||
+		!strings.Contains(in.URL, "action=getDomainList") {
+		t.Fatalf("unexpected interaction: %+v", in)
+	}
+
+	replay, err := onesecmailtest.NewReplayHTTPClient(filename)
+	if err != nil {
+		t.Fatalf("should not error: %v", err)
+	}
+	api = onesecmail.NewAPI(onesecmail.WithHTTPClient(replay))
+	var apiErr *onesecmail.APIError
+	if _, err := api.Domains(context.Background()); !errors.As(err, &apiErr) || apiErr.StatusCode != 500 {
+		t.Fatalf("expected APIError with status 500, got: %v", err)
+	}
+	if _, err := api.Domains(context.Background()); err == nil || !strings.Contains(err.Error(), "no interaction left") {
+		t.Fatalf("expected replay to be exhausted, got: %v", err)
+	}
+}
+
+func Test_ReplayHTTPClient_Mismatch(t *testing.T) {
+	replay, err := onesecmailtest.NewReplayHTTPClient(filepath.Join("testdata", "domains.json"))
+	if err != nil {
+		t.Fatalf("should not error: %v", err)
+	}
+	mailbox, err := onesecmail.NewMailbox("foo", "1secmail.com", onesecmail.WithHTTPClient(replay))
+	if err != nil {
+		t.Fatalf("should not error: %v", err)
+	}
+	if _, err := mailbox.CheckInbox(context.Background()); err == nil || !strings.Contains(err.Error(), "replay failed") {
+		t.Fatalf("expected replay mismatch, got: %v", err)
+	}
+}
@@ -0,0 +1,128 @@
+package onesecmailtest
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/z11i/onesecmail"
+)
+
+// NewServer starts and returns a new httptest.Server that serves the 1secmail API
+// with the mails in inboxes, keyed by email address. Point the client at it with
+// onesecmail.WithBaseURL. The domain list it serves is the domains of inboxes, and
+// attachments are downloaded with their Content.
+// The caller should call Close when finished, to shut it down.
+func NewServer(inboxes map[string][]*onesecmail.Mail) *httptest.Server {
+	s := &server{inboxes: make(map[string][]*onesecmail.Mail, len(inboxes))}
+	for address, mails := range inboxes {
+		s.inboxes[address] = append([]*onesecmail.Mail(nil), mails...)
+	}
+	return httptest.NewServer(s)
+}
+
+type server struct {
+	mu      sync.Mutex
+	inboxes map[string][]*onesecmail.Mail
+}
+
+// summary is a mail as listed by the getMessages action.
+type summary struct {
+	ID      int    `json:"id"`
+	From    string `json:"from"`
+	Subject string `json:"subject"`
+	Date    string `json:"date"`
+}
+
+func (s *server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	query := r.URL.Query()
+	address := query.Get("login") + "@" + query.Get("domain")
+	switch query.Get("action") {
+	case "getMessages":
+		summaries := []summary{}
+		for _, mail := range s.inboxes[address] {
+			summaries = append(summaries, summary{ID: mail.ID, From: mail.From, Subject: mail.Subject, Date: mail.Date})
+		}
+		writeJSON(w, summaries)
+	case "readMessage":
+		mail := s.find(address, query.Get("id"))
+		if mail == nil {
+			http.Error(w, "Message not found", http.StatusNotFound)
+			return
+		}
+		writeJSON(w, mail)
+	case "download":
+		mail := s.find(address, query.Get("id"))
+		if mail == nil {
+			http.Error(w, "Message not found", http.StatusNotFound)
+			return
+		}
+		for _, attachment := range mail.Attachments {
+			if attachment.Filename == query.Get("file") {
+				w.Header().Set("Content-Type", attachment.ContentType)
+				w.Write(attachment.Content)
+				return
+			}
+		}
+		http.Error(w, "Attachment not found", http.StatusNotFound)
+	case "deleteMessage":
+		mails := s.inboxes[address]
+		for i, mail := range mails {
+			if strconv.Itoa(mail.ID) == query.Get("id") {
+				s.inboxes[address] = append(mails[:i:i], mails[i+1:]...)
+				return
+			}
+		}
+		http.Error(w, "Message not found", http.StatusNotFound)
+	case "genRandomMailbox":
+		count, err := strconv.Atoi(query.Get("count"))
+		if err != nil || count < 1 {
+			count = 1
+		}
+		addresses := make([]string, count)
+		for i := range addresses {
+			addresses[i] = onesecmail.RandomLogin(10) + "@1secmail.com"
+		}
+		writeJSON(w, addresses)
+	case "getDomainList":
+		writeJSON(w, s.domains())
+	default:
+		http.Error(w, "Wrong action", http.StatusBadRequest)
+	}
+}
+
+// find returns the mail with id in the inbox of address, or nil if there is none.
+func (s *server) find(address, id string) *onesecmail.Mail {
+	for _, mail := range s.inboxes[address] {
+		if strconv.Itoa(mail.ID) == id {
+			return mail
+		}
+	}
+	return nil
+}
+
+func (s *server) domains() []string {
+	seen := make(map[string]struct{})
+	domains := []string{}
+	for address := range s.inboxes {
+		_, domain, _ := strings.Cut(address, "@")
+		if _, ok := seen[domain]; !ok && domain != "" {
+			seen[domain] = struct{}{}
+			domains = append(domains, domain)
+		}
+	}
+	sort.Strings(domains)
+	return domains
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
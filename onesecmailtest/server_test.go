@@ -0,0 +1,100 @@
+package onesecmailtest_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/z11i/onesecmail"
+	"github.com/z11i/onesecmail/onesecmailtest"
+)
+
+func Test_NewServer(t *testing.T) {
+	text := "Some message body"
+	server := onesecmailtest.NewServer(map[string][]*onesecmail.Mail{
+		"foo@1secmail.com": {
+			{ID: 639, From: "someone@example.com", Subject: "Some subject", Date: "2018-06-08 14:33:55",
+				Attachments: []onesecmail.Attachment{{Filename: "iometer.pdf", ContentType: "application/pdf", Size: 3, Content: []byte("pdf")}},
+				TextBody:    &text},
+			{ID: 640, From: "someoneelse@example.com", Subject: "Other subject", Date: "2018-06-08 14:40:55"},
+		},
+		"bar@1secmail.org": nil,
+	})
+	defer server.Close()
+	ctx := context.Background()
+
+	mailbox, err := onesecmail.NewMailbox("foo", "1secmail.com", onesecmail.WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatalf("should not error: %v", err)
+	}
+	mails, err := mailbox.CheckInbox(ctx)
+	if err != nil || len(mails) != 2 || mails[0].ID != 639 || mails[1].Subject != "Other subject" {
+		t.Fatalf("2 mails expected, got: %v, %v", mails, err)
+	}
+	if mails[0].TextBody != nil {
+		t.Fatal("inbox should only list summaries")
+	}
+
+	mail, err := mailbox.ReadMessage(ctx, 639)
+	if err != nil || mail.TextBody == nil || *mail.TextBody != text || len(mail.Attachments) != 1 {
+		t.Fatalf("mail 639 expected, got: %+v, %v", mail, err)
+	}
+	if _, err := mailbox.ReadMessage(ctx, 1); !errors.Is(err, onesecmail.ErrMessageNotFound) {
+		t.Fatalf("expected ErrMessageNotFound, got: %v", err)
+	}
+
+	rc, err := mailbox.DownloadAttachment(ctx, 639, "iometer.pdf")
+	if err != nil {
+		t.Fatalf("should not error: %v", err)
+	}
+	content, err := io.ReadAll(rc)
+	rc.Close()
+	if err != nil || string(content) != "pdf" {
+		t.Fatalf("attachment content expected: pdf, got: %q, %v", content, err)
+	}
+	if _, err := mailbox.DownloadAttachment(ctx, 639, "missing.pdf"); err == nil {
+		t.Fatal("should error")
+	}
+
+	other, err := mailbox.WithLogin("nobody")
+	if err != nil {
+		t.Fatalf("should not error: %v", err)
+	}
+	if mails, err := other.CheckInbox(ctx); err != nil || len(mails) != 0 {
+		t.Fatalf("empty inbox expected, got: %v, %v", mails, err)
+	}
+
+	addresses, err := mailbox.RandomAddresses(ctx, 3)
+	if err != nil || len(addresses) != 3 {
+		t.Fatalf("3 addresses expected, got: %v, %v", addresses, err)
+	}
+	domains, err := mailbox.Domains(ctx)
+	if err != nil || !reflect.DeepEqual(domains, []string{"1secmail.com", "1secmail.org"}) {
+		t.Fatalf("domains expected, got: %v, %v", domains, err)
+	}
+}
+
+func Test_NewServer_DeleteMessage(t *testing.T) {
+	server := onesecmailtest.NewServer(map[string][]*onesecmail.Mail{
+		"foo@1secmail.com": {{ID: 639}, {ID: 640}},
+	})
+	defer server.Close()
+	ctx := context.Background()
+
+	mailbox, err := onesecmail.NewMailbox("foo", "1secmail.com", onesecmail.WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatalf("should not error: %v", err)
+	}
+	if err := mailbox.DeleteMessage(ctx, 639); err != nil {
+		t.Fatalf("should not error: %v", err)
+	}
+	mails, err := mailbox.CheckInbox(ctx)
+	if err != nil || len(mails) != 1 || mails[0].ID != 640 {
+		t.Fatalf("only mail 640 expected, got: %v, %v", mails, err)
+	}
+	if err := mailbox.DeleteMessage(ctx, 639); !errors.Is(err, onesecmail.ErrMessageNotFound) {
+		t.Fatalf("expected ErrMessageNotFound, got: %v", err)
+	}
+}
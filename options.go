@@ -0,0 +1,207 @@
+package onesecmail
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+
+	"go.opentelemetry.io/otel/trace"
+	"golang.org/x/time/rate"
+)
+
+// Version is the version of this library, reported in the default User-Agent.
+const Version = "0.1.0"
+
+const (
+	// defaultUserAgent is the User-Agent of requests if none is set.
+	defaultUserAgent = "onesecmail-go/" + Version
+	// defaultBaseURL is the base URL of the 1secmail API.
+	defaultBaseURL = "https://www.1secmail.com/api/v1/"
+	// defaultMaxResponseBytes is the default maximum size of a response decoded as JSON.
+	defaultMaxResponseBytes = 1 << 20
+	// defaultReadConcurrency is the default number of mails read at once by ReadAllMessages.
+	defaultReadConcurrency = 5
+)
+
+// Option configures an API or a Mailbox.
+type Option func(*config)
+
+// config holds the settings of an API.
+type config struct {
+	httpClient     HTTPClient
+	baseURL        string
+	userAgent      string
+	headers        http.Header
+	timeout        time.Duration
+	logger         *slog.Logger
+	retry          *RetryConfig
+	jitter         JitterStrategy
+	rand           *lockedRand
+	breaker        *CircuitBreaker
+	limiter        *rate.Limiter
+	sharedLimiter  bool
+	observer       Observer
+	metrics        MetricsCollector
+	domainCache    *domainCache
+	tracerProvider trace.TracerProvider
+
+	maxResponseBytes int64
+	readConcurrency  int
+}
+
+func newConfig(opts []Option) *config {
+	cfg := &config{
+		jitter:           FullJitter,
+		maxResponseBytes: defaultMaxResponseBytes,
+		readConcurrency:  defaultReadConcurrency,
+	}
+	for _, opt := range opts {
+		if opt != nil {
+			opt(cfg)
+		}
+	}
+	if cfg.httpClient == nil {
+		cfg.httpClient = http.DefaultClient
+	}
+	if cfg.baseURL == "" {
+		cfg.baseURL = os.Getenv(EnvBaseURL)
+	}
+	if cfg.baseURL == "" {
+		cfg.baseURL = defaultBaseURL
+	}
+	if cfg.userAgent == "" {
+		cfg.userAgent = defaultUserAgent
+	}
+	if cfg.metrics == nil {
+		cfg.metrics = NoopMetricsCollector{}
+	}
+	return cfg
+}
+
+// client returns the HTTPClient that makes requests with the settings of cfg.
+func (cfg *config) client() HTTPClient {
+	client := cfg.httpClient
+	if cfg.limiter != nil {
+		client = rateLimitClient{next: client, limiter: cfg.limiter}
+	}
+	if cfg.retry != nil {
+		client = retryClient{next: client, cfg: *cfg.retry, jitter: cfg.jitter, rand: cfg.rand, logger: cfg.logger,
+			metrics: cfg.metrics}
+	}
+	if cfg.breaker != nil {
+		client = breakerClient{next: client, breaker: cfg.breaker}
+	}
+	return client
+}
+
+// clone returns a copy of cfg that does not share its mutable state: the headers,
+// the circuit breaker, the domain cache, and the rate limiter set by WithRateLimit
+// are replaced by new ones with the same settings. The HTTP client, logger, observer,
+// metrics, tracer provider and random source are shared, as is a rate limiter set by
+// WithRateLimiter, which is meant to be shared.
+func (cfg *config) clone() *config {
+	c := *cfg
+	c.headers = cfg.headers.Clone()
+	if cfg.breaker != nil {
+		c.breaker = &CircuitBreaker{threshold: cfg.breaker.threshold, resetTimeout: cfg.breaker.resetTimeout}
+	}
+	if cfg.limiter != nil && !cfg.sharedLimiter {
+		c.limiter = rate.NewLimiter(cfg.limiter.Limit(), cfg.limiter.Burst())
+	}
+	if cfg.domainCache != nil {
+		c.domainCache = &domainCache{ttl: cfg.domainCache.ttl}
+	}
+	return &c
+}
+
+// WithHTTPClient sets the HTTPClient used to make requests. If it is not set,
+// or c is nil, http.DefaultClient is used.
+func WithHTTPClient(c HTTPClient) Option {
+	return func(cfg *config) {
+		cfg.httpClient = c
+	}
+}
+
+// WithBaseURL sets the base URL of the API, e.g. to use a mock server in tests.
+// If it is not set, or url is empty, the ONESECMAIL_BASE_URL environment variable is
+// used if it is set, or else https://www.1secmail.com/api/v1/.
+func WithBaseURL(url string) Option {
+	return func(cfg *config) {
+		cfg.baseURL = url
+	}
+}
+
+// WithUserAgent sets the User-Agent header of requests. If it is not set, or ua is
+// empty, "onesecmail-go/" followed by Version is used.
+func WithUserAgent(ua string) Option {
+	return func(cfg *config) {
+		cfg.userAgent = ua
+	}
+}
+
+// WithHeader adds a header to every request, e.g. for a proxy that requires
+// authentication. It can be given several times, and values of the same key are
+// all sent. A header set this way replaces the one set by the library, such as
+// User-Agent.
+func WithHeader(key, value string) Option {
+	return func(cfg *config) {
+		if cfg.headers == nil {
+			cfg.headers = make(http.Header)
+		}
+		cfg.headers.Add(key, value)
+	}
+}
+
+// WithTimeout sets a timeout for each request. It applies on top of the deadline
+// of the context passed to each method, so the earlier deadline wins. A zero or
+// negative timeout means no timeout.
+func WithTimeout(d time.Duration) Option {
+	return func(cfg *config) {
+		cfg.timeout = d
+	}
+}
+
+// WithLogger sets the logger used to log requests. Each request is logged at debug
+// level with its action, method and URL when it is sent, and with its status code
+// and latency when it is done. Failed requests, responses with a status code other
+// than 200, and retries are logged at warn level. Records are logged with the
+// context of the request. If it is not set, or l is nil, nothing is logged.
+func WithLogger(l *slog.Logger) Option {
+	return func(cfg *config) {
+		cfg.logger = l
+	}
+}
+
+// WithMaxResponseBytes sets the maximum number of bytes read from a response that is
+// decoded as JSON, to guard against unexpectedly large responses. If it is not set,
+// the maximum is 1 MB. Zero or a negative n means no maximum. Attachment downloads
+// are streamed to the caller and are not limited.
+func WithMaxResponseBytes(n int64) Option {
+	return func(cfg *config) {
+		cfg.maxResponseBytes = n
+	}
+}
+
+// WithReadConcurrency sets the maximum number of mails read at once by
+// ReadAllMessages. If it is not set, or n is not positive, 5 mails are read at once.
+func WithReadConcurrency(n int) Option {
+	return func(cfg *config) {
+		if n > 0 {
+			cfg.readConcurrency = n
+		}
+	}
+}
+
+// cancelOnClose cancels a context when the body it wraps is closed.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c cancelOnClose) Close() error {
+	defer c.cancel()
+	return c.ReadCloser.Close()
+}
@@ -0,0 +1,367 @@
+package onesecmail_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"io/ioutil"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/z11i/onesecmail"
+)
+
+func Test_WithBaseURL(t *testing.T) {
+	var gotURL string
+	client := &ClientMock{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			gotURL = req.URL.Scheme + "://" + req.URL.Host + req.URL.Path
+			return &http.Response{StatusCode: 200, Body: ioutil.NopCloser(strings.NewReader(`[]`))}, nil
+		},
+	}
+	tests := []struct {
+		name   string
+		opts   []onesecmail.Option
+		expURL string
+	}{
+		{name: "default", opts: []onesecmail.Option{onesecmail.WithHTTPClient(client)}, expURL: "https://www.1secmail.com/api/v1/"},
+		{name: "custom", opts: []onesecmail.Option{onesecmail.WithHTTPClient(client), onesecmail.WithBaseURL("http://127.0.0.1:8080/api/")}, expURL: "http://127.0.0.1:8080/api/"},
+		{name: "nil option", opts: []onesecmail.Option{nil, onesecmail.WithHTTPClient(client)}, expURL: "https://www.1secmail.com/api/v1/"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mailbox, err := onesecmail.NewMailbox("foo", "1secmail.org", test.opts...)
+			if err != nil {
+				t.Fatal("should not error")
+			}
+			if _, err := mailbox.CheckInbox(context.Background()); err != nil {
+				t.Fatalf("should not error: %v", err)
+			}
+			if gotURL != test.expURL {
+				t.Fatalf("URL expected: %s, got: %s", test.expURL, gotURL)
+			}
+		})
+	}
+}
+
+func Test_WithBaseURL_Malformed(t *testing.T) {
+	client := &ClientMock{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			t.Fatal("no request should be made")
+			return nil, nil
+		},
+	}
+	mailbox, err := onesecmail.NewMailbox("foo", "1secmail.org",
+		onesecmail.WithHTTPClient(client), onesecmail.WithBaseURL("http://[::1"))
+	if err != nil {
+		t.Fatal("should not error")
+	}
+	ctx := context.Background()
+	calls := map[string]func() error{
+		"check inbox failed":             func() error { _, err := mailbox.CheckInbox(ctx); return err },
+		"read message failed":            func() error { _, err := mailbox.ReadMessage(ctx, 1); return err },
+		"delete message failed":          func() error { return mailbox.DeleteMessage(ctx, 1) },
+		"download attachment failed":     func() error { _, err := mailbox.DownloadAttachment(ctx, 1, "a.pdf"); return err },
+		"generate random mailbox failed": func() error { _, err := mailbox.RandomAddresses(ctx, 1); return err },
+		"get domain list failed":         func() error { _, err := mailbox.Domains(ctx); return err },
+	}
+	for expErr, call := range calls {
+		t.Run(expErr, func(t *testing.T) {
+			err := call()
+			if err == nil || !strings.Contains(err.Error(), expErr) {
+				t.Fatalf("error expected: %s, got: %v", expErr, err)
+			}
+		})
+	}
+}
+
+func Test_WithTimeout(t *testing.T) {
+	client := &ClientMock{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			<-req.Context().Done()
+			return nil, req.Context().Err()
+		},
+	}
+	api := onesecmail.NewAPI(onesecmail.WithHTTPClient(client), onesecmail.WithTimeout(10*time.Millisecond))
+	_, err := api.Domains(context.Background())
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context.DeadlineExceeded, got: %v", err)
+	}
+}
+
+func Test_WithTimeout_ShorterDeadlineWins(t *testing.T) {
+	tests := []struct {
+		name        string
+		timeout     time.Duration
+		ctxTimeout  time.Duration
+		expDeadline time.Duration
+	}{
+		{name: "timeout shorter", timeout: time.Minute, ctxTimeout: time.Hour, expDeadline: time.Minute},
+		{name: "context shorter", timeout: time.Hour, ctxTimeout: time.Minute, expDeadline: time.Minute},
+		{name: "no timeout", timeout: 0, ctxTimeout: time.Hour, expDeadline: time.Hour},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var deadline time.Time
+			client := &ClientMock{
+				DoFunc: func(req *http.Request) (*http.Response, error) {
+					deadline, _ = req.Context().Deadline()
+					return &http.Response{StatusCode: 200, Body: ioutil.NopCloser(strings.NewReader(`[]`))}, nil
+				},
+			}
+			api := onesecmail.NewAPI(onesecmail.WithHTTPClient(client), onesecmail.WithTimeout(test.timeout))
+			ctx, cancel := context.WithTimeout(context.Background(), test.ctxTimeout)
+			defer cancel()
+			if _, err := api.Domains(ctx); err != nil {
+				t.Fatalf("should not error: %v", err)
+			}
+			if diff := time.Until(deadline) - test.expDeadline; diff < -time.Second || diff > time.Second {
+				t.Fatalf("deadline expected in: %v, got: %v", test.expDeadline, time.Until(deadline))
+			}
+		})
+	}
+}
+
+func Test_WithTimeout_DoesNotMutateClient(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`[]`))
+	}))
+	defer server.Close()
+	client := &http.Client{}
+	api := onesecmail.NewAPI(onesecmail.WithHTTPClient(client), onesecmail.WithBaseURL(server.URL),
+		onesecmail.WithTimeout(time.Second))
+	if _, err := api.Domains(context.Background()); err != nil {
+		t.Fatalf("should not error: %v", err)
+	}
+	if client.Timeout != 0 {
+		t.Fatalf("client timeout should not be changed, got: %v", client.Timeout)
+	}
+}
+
+func Test_WithLogger(t *testing.T) {
+	client := &ClientMock{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			return &http.Response{StatusCode: 200, Body: ioutil.NopCloser(strings.NewReader(`[]`))}, nil
+		},
+	}
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	api := onesecmail.NewAPI(onesecmail.WithHTTPClient(client), onesecmail.WithLogger(logger))
+	if _, err := api.Domains(context.Background()); err != nil {
+		t.Fatalf("should not error: %v", err)
+	}
+	for _, exp := range []string{"level=DEBUG", `msg="sending request"`, "method=GET", `msg="request done"`,
+		"action=getDomainList", `url="https://www.1secmail.com/api/v1/?action=getDomainList"`, "status=200", "latency="} {
+		if !strings.Contains(buf.String(), exp) {
+			t.Fatalf("expected %q to be logged, got: %s", exp, buf.String())
+		}
+	}
+}
+
+func Test_WithLogger_Warn(t *testing.T) {
+	calls := 0
+	client := &ClientMock{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			calls++
+			if calls == 1 {
+				return nil, errors.New("connection reset")
+			}
+			return &http.Response{StatusCode: 500, Body: ioutil.NopCloser(strings.NewReader(`oops`))}, nil
+		},
+	}
+	var buf bytes.Buffer
+	logger := slog.New(slog.NewTextHandler(&buf, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	api := onesecmail.NewAPI(onesecmail.WithHTTPClient(client), onesecmail.WithLogger(logger.With("test", "warn")),
+		onesecmail.WithRetry(onesecmail.RetryConfig{
+			MaxAttempts:          2,
+			RetryableStatusCodes: []int{503},
+			Sleep:                func(ctx context.Context, d time.Duration) error { return nil },
+		}))
+	if _, err := api.Domains(context.Background()); err == nil {
+		t.Fatal("should error")
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	exps := [][]string{
+		{"level=WARN", `msg="retrying request"`, "action=getDomainList", "attempt=1", `error="connection reset"`, "test=warn"},
+		{"level=WARN", `msg="unexpected status"`, "action=getDomainList", "status=500", "test=warn"},
+	}
+	if len(lines) != len(exps) {
+		t.Fatalf("%d records expected, got: %s", len(exps), buf.String())
+	}
+	for i, exp := range exps {
+		for _, field := range exp {
+			if !strings.Contains(lines[i], field) {
+				t.Fatalf("expected %q to be logged, got: %s", field, lines[i])
+			}
+		}
+	}
+}
+
+func Test_WithLogger_Nil(t *testing.T) {
+	client := &ClientMock{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			return nil, errors.New("network down")
+		},
+	}
+	api := onesecmail.NewAPI(onesecmail.WithHTTPClient(client), onesecmail.WithLogger(nil))
+	if _, err := api.Domains(context.Background()); err == nil {
+		t.Fatal("should error")
+	}
+}
+
+func Test_WithBaseURL_TestServer(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Query().Get("action") {
+		case "getMessages":
+			w.Write([]byte(`[{"id":639,"from":"someone@example.com","subject":"Some subject","date":"2018-06-08 14:33:55"}]`))
+		case "readMessage":
+			w.Write([]byte(`{"id":639,"from":"someone@example.com","subject":"Some subject","date":"2018-06-08 14:33:55","textBody":"hello"}`))
+		default:
+			w.WriteHeader(http.StatusBadRequest)
+		}
+	}))
+	defer server.Close()
+
+	mailbox, err := onesecmail.NewMailbox("foo", "1secmail.org", onesecmail.WithBaseURL(server.URL))
+	if err != nil {
+		t.Fatal("should not error")
+	}
+	mails, err := mailbox.CheckInbox(context.Background())
+	if err != nil {
+		t.Fatalf("should not error: %v", err)
+	}
+	if len(mails) != 1 {
+		t.Fatal("len not expected")
+	}
+	mail, err := mailbox.ReadMessage(context.Background(), mails[0].ID)
+	if err != nil {
+		t.Fatalf("should not error: %v", err)
+	}
+	if mail.TextBody == nil || *mail.TextBody != "hello" {
+		t.Fatal("text body not expected")
+	}
+}
+
+func Test_WithMaxResponseBytes(t *testing.T) {
+	large := `["` + strings.Repeat("a", 2<<20) + `@1secmail.com"]`
+	tests := []struct {
+		name     string
+		opts     []onesecmail.Option
+		respBody string
+		expErr   error
+	}{
+		{name: "default limit", respBody: large, expErr: onesecmail.ErrResponseTooLarge},
+		{name: "within default limit", respBody: `["1secmail.com"]`},
+		{name: "custom limit", opts: []onesecmail.Option{onesecmail.WithMaxResponseBytes(8)}, respBody: `["1secmail.com"]`, expErr: onesecmail.ErrResponseTooLarge},
+		{name: "unlimited", opts: []onesecmail.Option{onesecmail.WithMaxResponseBytes(0)}, respBody: large},
+		{name: "malformed within limit", respBody: `[`, expErr: onesecmail.ErrDecodeJSON},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := &ClientMock{
+				DoFunc: func(req *http.Request) (*http.Response, error) {
+					return &http.Response{StatusCode: 200, Body: ioutil.NopCloser(strings.NewReader(test.respBody))}, nil
+				},
+			}
+			api := onesecmail.NewAPI(append(test.opts, onesecmail.WithHTTPClient(client))...)
+			_, err := api.Domains(context.Background())
+			if test.expErr == nil && err != nil {
+				t.Fatalf("should not error: %v", err)
+			}
+			if test.expErr != nil && !errors.Is(err, test.expErr) {
+				t.Fatalf("expected %v, got: %v", test.expErr, err)
+			}
+			if test.expErr == onesecmail.ErrDecodeJSON && errors.Is(err, onesecmail.ErrResponseTooLarge) {
+				t.Fatalf("malformed response should not report the limit: %v", err)
+			}
+		})
+	}
+}
+
+func Test_WithUserAgent(t *testing.T) {
+	var gotUA string
+	client := &ClientMock{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			gotUA = req.Header.Get("User-Agent")
+			return &http.Response{StatusCode: 200, Body: ioutil.NopCloser(strings.NewReader(`[]`))}, nil
+		},
+	}
+	tests := []struct {
+		name  string
+		opts  []onesecmail.Option
+		expUA string
+	}{
+		{name: "default", expUA: "onesecmail-go/" + onesecmail.Version},
+		{name: "custom", opts: []onesecmail.Option{onesecmail.WithUserAgent("my-app/1.0")}, expUA: "my-app/1.0"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mailbox, err := onesecmail.NewMailbox("foo", "1secmail.org", append(test.opts, onesecmail.WithHTTPClient(client))...)
+			if err != nil {
+				t.Fatal("should not error")
+			}
+			if _, err := mailbox.CheckInbox(context.Background()); err != nil {
+				t.Fatalf("should not error: %v", err)
+			}
+			if gotUA != test.expUA {
+				t.Fatalf("User-Agent expected: %s, got: %s", test.expUA, gotUA)
+			}
+			if _, err := mailbox.Domains(context.Background()); err != nil {
+				t.Fatalf("should not error: %v", err)
+			}
+			if gotUA != test.expUA {
+				t.Fatalf("User-Agent expected: %s, got: %s", test.expUA, gotUA)
+			}
+		})
+	}
+}
+
+func Test_WithHeader(t *testing.T) {
+	var got http.Header
+	client := &ClientMock{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			got = req.Header.Clone()
+			return &http.Response{StatusCode: 200, Body: ioutil.NopCloser(strings.NewReader(`[]`))}, nil
+		},
+	}
+	tests := []struct {
+		name string
+		opts []onesecmail.Option
+		exp  http.Header
+	}{
+		{
+			name: "custom headers",
+			opts: []onesecmail.Option{
+				onesecmail.WithHeader("X-Proxy-Auth", "secret"),
+				onesecmail.WithHeader("x-tag", "a"),
+				onesecmail.WithHeader("X-Tag", "b"),
+			},
+			exp: http.Header{
+				"User-Agent":   {"onesecmail-go/" + onesecmail.Version},
+				"X-Proxy-Auth": {"secret"},
+				"X-Tag":        {"a", "b"},
+			},
+		},
+		{
+			name: "override user agent",
+			opts: []onesecmail.Option{onesecmail.WithHeader("User-Agent", "gateway/2.0")},
+			exp:  http.Header{"User-Agent": {"gateway/2.0"}},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			api := onesecmail.NewAPI(append(test.opts, onesecmail.WithHTTPClient(client))...)
+			if _, err := api.Domains(context.Background()); err != nil {
+				t.Fatalf("should not error: %v", err)
+			}
+			if !reflect.DeepEqual(got, test.exp) {
+				t.Fatalf("headers expected: %v, got: %v", test.exp, got)
+			}
+		})
+	}
+}
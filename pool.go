@@ -0,0 +1,87 @@
+package onesecmail
+
+import (
+	"context"
+	"fmt"
+	"sync"
+)
+
+// MailboxPool is a fixed set of random mailboxes that can be acquired for exclusive
+// use, e.g. by parallel tests, and released for reuse. It is safe for concurrent use.
+type MailboxPool struct {
+	idle  chan Mailbox
+	total int
+
+	mu    sync.Mutex
+	inUse int
+}
+
+// PoolStats reports the number of mailboxes in a MailboxPool.
+type PoolStats struct {
+	Total int
+	Idle  int
+	InUse int
+}
+
+// NewMailboxPool returns a MailboxPool of size random mailboxes, generated with an
+// API configured by opts, at most MaxRandomAddresses per request.
+func NewMailboxPool(ctx context.Context, size int, opts ...Option) (*MailboxPool, error) {
+	if size < 1 {
+		return nil, fmt.Errorf("create mailbox pool failed: invalid size: %d", size)
+	}
+	api := NewAPI(opts...)
+	addresses := make([]string, 0, size)
+	for len(addresses) < size {
+		n := min(size-len(addresses), MaxRandomAddresses)
+		batch, err := api.RandomAddresses(ctx, n)
+		if err != nil {
+			return nil, err
+		}
+		if len(batch) != n {
+			return nil, fmt.Errorf("create mailbox pool failed: got %d addresses, want %d", len(batch), n)
+		}
+		addresses = append(addresses, batch...)
+	}
+
+	p := &MailboxPool{idle: make(chan Mailbox, size), total: size}
+	for _, address := range addresses {
+		mb, err := api.mailbox(address)
+		if err != nil {
+			return nil, err
+		}
+		p.idle <- mb
+	}
+	return p, nil
+}
+
+// Acquire returns an idle mailbox from the pool. It blocks until one is released if
+// none is idle, or until ctx is done, in which case ctx.Err() is returned.
+func (p *MailboxPool) Acquire(ctx context.Context) (Mailbox, error) {
+	select {
+	case mb := <-p.idle:
+		p.mu.Lock()
+		p.inUse++
+		p.mu.Unlock()
+		return mb, nil
+	case <-ctx.Done():
+		return Mailbox{}, ctx.Err()
+	}
+}
+
+// Release returns a mailbox acquired from the pool, so that it can be acquired again.
+func (p *MailboxPool) Release(mb Mailbox) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.inUse == 0 {
+		return
+	}
+	p.inUse--
+	p.idle <- mb
+}
+
+// Stats reports the number of mailboxes in the pool.
+func (p *MailboxPool) Stats() PoolStats {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return PoolStats{Total: p.total, Idle: p.total - p.inUse, InUse: p.inUse}
+}
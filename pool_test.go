@@ -0,0 +1,109 @@
+package onesecmail_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/z11i/onesecmail"
+)
+
+func randomAddressesClient() *ClientMock {
+	return &ClientMock{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			count, _ := strconv.Atoi(req.URL.Query().Get("count"))
+			addresses := make([]string, count)
+			for i := range addresses {
+				addresses[i] = fmt.Sprintf(`"box%d@1secmail.com"`, i)
+			}
+			body := "[" + strings.Join(addresses, ",") + "]"
+			return &http.Response{StatusCode: 200, Body: ioutil.NopCloser(strings.NewReader(body))}, nil
+		},
+	}
+}
+
+func Test_MailboxPool(t *testing.T) {
+	pool, err := onesecmail.NewMailboxPool(context.Background(), 2, onesecmail.WithHTTPClient(randomAddressesClient()))
+	if err != nil {
+		t.Fatalf("should not error: %v", err)
+	}
+	if stats := pool.Stats(); stats != (onesecmail.PoolStats{Total: 2, Idle: 2}) {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+
+	first, err := pool.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("should not error: %v", err)
+	}
+	second, err := pool.Acquire(context.Background())
+	if err != nil {
+		t.Fatalf("should not error: %v", err)
+	}
+	if first.Address() == second.Address() {
+		t.Fatal("acquired mailboxes should be distinct")
+	}
+	if stats := pool.Stats(); stats != (onesecmail.PoolStats{Total: 2, InUse: 2}) {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	if _, err := pool.Acquire(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Fatalf("expected context.DeadlineExceeded, got: %v", err)
+	}
+
+	released := make(chan onesecmail.Mailbox)
+	go func() {
+		mb, _ := pool.Acquire(context.Background())
+		released <- mb
+	}()
+	pool.Release(first)
+	if mb := <-released; mb.Address() != first.Address() {
+		t.Fatalf("expected released mailbox %s, got: %s", first.Address(), mb.Address())
+	}
+	pool.Release(second)
+	if stats := pool.Stats(); stats != (onesecmail.PoolStats{Total: 2, Idle: 1, InUse: 1}) {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+}
+
+func Test_NewMailboxPool_Batches(t *testing.T) {
+	var counts []string
+	client := randomAddressesClient()
+	do := client.DoFunc
+	client.DoFunc = func(req *http.Request) (*http.Response, error) {
+		counts = append(counts, req.URL.Query().Get("count"))
+		return do(req)
+	}
+	pool, err := onesecmail.NewMailboxPool(context.Background(), 23, onesecmail.WithHTTPClient(client))
+	if err != nil {
+		t.Fatalf("should not error: %v", err)
+	}
+	if stats := pool.Stats(); stats.Total != 23 || stats.Idle != 23 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+	if exp := []string{"10", "10", "3"}; !reflect.DeepEqual(counts, exp) {
+		t.Fatalf("counts expected: %v, got: %v", exp, counts)
+	}
+}
+
+func Test_NewMailboxPool_Errors(t *testing.T) {
+	if _, err := onesecmail.NewMailboxPool(context.Background(), 0, onesecmail.WithHTTPClient(randomAddressesClient())); err == nil {
+		t.Fatal("invalid size should error")
+	}
+	client := &ClientMock{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			return &http.Response{StatusCode: 500, Body: ioutil.NopCloser(strings.NewReader(""))}, nil
+		},
+	}
+	if _, err := onesecmail.NewMailboxPool(context.Background(), 2, onesecmail.WithHTTPClient(client)); err == nil {
+		t.Fatal("API failure should error")
+	}
+}
@@ -0,0 +1,46 @@
+package onesecmail
+
+import (
+	"fmt"
+	"net/http"
+
+	"golang.org/x/time/rate"
+)
+
+// WithRateLimit limits requests to rps per second, allowing bursts of up to burst
+// requests. A request waits for the limit, or fails with an error wrapping the
+// context's error if the context is done first. Retries set by WithRetry are
+// limited too.
+func WithRateLimit(rps float64, burst int) Option {
+	return func(cfg *config) {
+		cfg.limiter = rate.NewLimiter(rate.Limit(rps), burst)
+		cfg.sharedLimiter = false
+	}
+}
+
+// WithRateLimiter limits requests with l, as in WithRateLimit. Unlike WithRateLimit,
+// the same limiter can be shared by several mailboxes, e.g. to poll many mailboxes
+// concurrently while keeping the total rate of requests within the API's limits.
+// Copies made by Mailbox.Clone and Mailbox.WithClient share l too.
+func WithRateLimiter(l *rate.Limiter) Option {
+	return func(cfg *config) {
+		cfg.limiter = l
+		cfg.sharedLimiter = true
+	}
+}
+
+// rateLimitClient is an HTTPClient that makes requests with next within a rate limit.
+type rateLimitClient struct {
+	next    HTTPClient
+	limiter *rate.Limiter
+}
+
+func (c rateLimitClient) Do(req *http.Request) (*http.Response, error) {
+	if err := c.limiter.Wait(req.Context()); err != nil {
+		if ctxErr := req.Context().Err(); ctxErr != nil {
+			return nil, fmt.Errorf("wait for rate limit failed: %w", ctxErr)
+		}
+		return nil, fmt.Errorf("wait for rate limit failed: %w", err)
+	}
+	return c.next.Do(req)
+}
@@ -0,0 +1,89 @@
+package onesecmail_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/z11i/onesecmail"
+	"golang.org/x/time/rate"
+)
+
+func Test_WithRateLimit(t *testing.T) {
+	client := &ClientMock{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			return &http.Response{StatusCode: 200, Body: ioutil.NopCloser(strings.NewReader(`[]`))}, nil
+		},
+	}
+	api := onesecmail.NewAPI(onesecmail.WithHTTPClient(client), onesecmail.WithRateLimit(20, 3))
+
+	start := time.Now()
+	for i := 0; i < 3; i++ {
+		if _, err := api.Domains(context.Background()); err != nil {
+			t.Fatalf("should not error: %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed > 30*time.Millisecond {
+		t.Fatalf("burst should complete quickly, took: %v", elapsed)
+	}
+
+	start = time.Now()
+	for i := 0; i < 2; i++ {
+		if _, err := api.Domains(context.Background()); err != nil {
+			t.Fatalf("should not error: %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 80*time.Millisecond {
+		t.Fatalf("requests beyond the burst should be delayed, took: %v", elapsed)
+	}
+}
+
+func Test_WithRateLimit_ContextDone(t *testing.T) {
+	client := &ClientMock{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			return &http.Response{StatusCode: 200, Body: ioutil.NopCloser(strings.NewReader(`[]`))}, nil
+		},
+	}
+	api := onesecmail.NewAPI(onesecmail.WithHTTPClient(client), onesecmail.WithRateLimit(0.1, 1))
+	if _, err := api.Domains(context.Background()); err != nil {
+		t.Fatalf("should not error: %v", err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(10*time.Millisecond, cancel)
+	_, err := api.Domains(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got: %v", err)
+	}
+}
+
+func Test_WithRateLimiter_Shared(t *testing.T) {
+	client := &ClientMock{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			return &http.Response{StatusCode: 200, Body: ioutil.NopCloser(strings.NewReader(`[]`))}, nil
+		},
+	}
+	limiter := rate.NewLimiter(20, 1)
+	var mailboxes []onesecmail.Mailbox
+	for _, login := range []string{"foo", "bar", "baz"} {
+		mb, err := onesecmail.NewMailbox(login, "1secmail.org", onesecmail.WithHTTPClient(client), onesecmail.WithRateLimiter(limiter))
+		if err != nil {
+			t.Fatal("should not error")
+		}
+		mailboxes = append(mailboxes, mb)
+	}
+
+	start := time.Now()
+	for _, mb := range mailboxes {
+		if _, err := mb.CheckInbox(context.Background()); err != nil {
+			t.Fatalf("should not error: %v", err)
+		}
+	}
+	if elapsed := time.Since(start); elapsed < 80*time.Millisecond {
+		t.Fatalf("mailboxes should share the rate limit, took: %v", elapsed)
+	}
+}
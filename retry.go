@@ -0,0 +1,207 @@
+package onesecmail
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"math"
+	"math/rand"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// RetryConfig configures how requests that failed with a network error or a
+// retryable status code are retried. Requests are retried with exponential
+// backoff: the delay is multiplied by Multiplier after each attempt, up to
+// MaxDelay, and a random jitter set by WithJitter picks the actual delay.
+//
+// Once all attempts are exhausted, the last network error is returned wrapped
+// with the number of attempts, or the last response is handled as usual, so
+// that its status code is reported in an *APIError.
+type RetryConfig struct {
+	// MaxAttempts is the maximum number of attempts, including the first one.
+	// Requests are not retried if it is less than 2.
+	MaxAttempts int
+	// InitialDelay is the base delay before the first retry.
+	InitialDelay time.Duration
+	// Multiplier is the factor the delay grows by after each attempt. If it is
+	// less than 1, the delay doubles.
+	Multiplier float64
+	// MaxDelay caps the delay between attempts. Zero means no cap.
+	MaxDelay time.Duration
+	// RetryableStatusCodes are the status codes of responses that are retried.
+	// If it is empty, responses with status 429, 500, 502 or 503 are retried.
+	RetryableStatusCodes []int
+	// Sleep waits for d, or until ctx is done. If it is nil, a timer is used.
+	// It can be replaced to observe the delays in tests.
+	Sleep func(ctx context.Context, d time.Duration) error
+}
+
+// WithRetry retries failed requests as configured by cfg.
+func WithRetry(cfg RetryConfig) Option {
+	return func(c *config) {
+		c.retry = &cfg
+	}
+}
+
+// JitterStrategy randomizes the delays between retries, so that many clients
+// failing at once do not retry in lockstep.
+type JitterStrategy int
+
+const (
+	// NoJitter uses the computed delay as is.
+	NoJitter JitterStrategy = iota
+	// FullJitter picks a random delay between zero and the computed delay.
+	FullJitter
+	// EqualJitter picks a random delay between half the computed delay and the computed delay.
+	EqualJitter
+)
+
+// WithJitter sets how delays between retries set by WithRetry are randomized.
+// If it is not set, FullJitter is used.
+func WithJitter(strategy JitterStrategy) Option {
+	return func(c *config) {
+		c.jitter = strategy
+	}
+}
+
+// WithRand sets the source of randomness for jitter, e.g. a seeded one in tests.
+// If it is not set, or r is nil, the default source of math/rand is used.
+func WithRand(r *rand.Rand) Option {
+	return func(c *config) {
+		c.rand = nil
+		if r != nil {
+			c.rand = &lockedRand{r: r}
+		}
+	}
+}
+
+// lockedRand makes a *rand.Rand safe for concurrent use.
+type lockedRand struct {
+	mu sync.Mutex
+	r  *rand.Rand
+}
+
+// int63n returns a random number in [0, n).
+func (r *lockedRand) int63n(n int64) int64 {
+	if r == nil {
+		return rand.Int63n(n)
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.r.Int63n(n)
+}
+
+// defaultRetryableStatusCodes are the status codes retried if none are configured.
+var defaultRetryableStatusCodes = []int{
+	http.StatusTooManyRequests,
+	http.StatusInternalServerError,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+}
+
+// retryClient is an HTTPClient that retries requests made with next.
+type retryClient struct {
+	next    HTTPClient
+	cfg     RetryConfig
+	jitter  JitterStrategy
+	rand    *lockedRand
+	logger  *slog.Logger
+	metrics MetricsCollector
+}
+
+func (c retryClient) Do(req *http.Request) (*http.Response, error) {
+	ctx := req.Context()
+	for attempt := 1; ; attempt++ {
+		resp, err := c.next.Do(req)
+		if ctx.Err() != nil || !c.retryable(resp, err) {
+			return resp, err
+		}
+		if attempt >= c.cfg.MaxAttempts {
+			if err != nil && attempt > 1 {
+				err = fmt.Errorf("giving up after %d attempts: %w", attempt, err)
+			}
+			return resp, err
+		}
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		delay := c.delay(attempt)
+		action := req.URL.Query().Get("action")
+		c.metrics.RecordRetryAttempt(action, attempt)
+		if c.logger != nil {
+			attrs := []slog.Attr{slog.String("action", action),
+				slog.Int("attempt", attempt), slog.Duration("delay", delay)}
+			if err != nil {
+				attrs = append(attrs, slog.Any("error", err))
+			} else {
+				attrs = append(attrs, slog.Int("status", resp.StatusCode))
+			}
+			c.logger.LogAttrs(ctx, slog.LevelWarn, "retrying request", attrs...)
+		}
+		if err := c.sleep(ctx, delay); err != nil {
+			return nil, err
+		}
+	}
+}
+
+func (c retryClient) retryable(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	codes := c.cfg.RetryableStatusCodes
+	if len(codes) == 0 {
+		codes = defaultRetryableStatusCodes
+	}
+	for _, code := range codes {
+		if resp.StatusCode == code {
+			return true
+		}
+	}
+	return false
+}
+
+// delay returns the delay before retrying after attempt.
+func (c retryClient) delay(attempt int) time.Duration {
+	multiplier := c.cfg.Multiplier
+	if multiplier < 1 {
+		multiplier = 2
+	}
+	d := float64(c.cfg.InitialDelay) * math.Pow(multiplier, float64(attempt-1))
+	if c.cfg.MaxDelay > 0 && d > float64(c.cfg.MaxDelay) {
+		d = float64(c.cfg.MaxDelay)
+	}
+	if !(d > 0) {
+		return 0
+	}
+	// Without MaxDelay, d can overflow an int64, so it is clamped such that n+1 does not.
+	n := int64(math.MaxInt64 - 1)
+	if d < float64(n) {
+		n = int64(d)
+	}
+	switch c.jitter {
+	case FullJitter:
+		n = c.rand.int63n(n + 1)
+	case EqualJitter:
+		half := n / 2
+		n = half + c.rand.int63n(n-half+1)
+	}
+	return time.Duration(n)
+}
+
+func (c retryClient) sleep(ctx context.Context, d time.Duration) error {
+	if c.cfg.Sleep != nil {
+		return c.cfg.Sleep(ctx, d)
+	}
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
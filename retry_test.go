@@ -0,0 +1,255 @@
+package onesecmail_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"math"
+	"math/rand"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/z11i/onesecmail"
+)
+
+// failingClient returns a client that fails the first failures calls with failure,
+// and then succeeds. It counts the calls in calls.
+func failingClient(failures int, failure func() (*http.Response, error), calls *int) *ClientMock {
+	return &ClientMock{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			*calls++
+			if *calls <= failures {
+				return failure()
+			}
+			return &http.Response{StatusCode: 200, Body: ioutil.NopCloser(strings.NewReader(`["1secmail.com"]`))}, nil
+		},
+	}
+}
+
+func statusFailure(code int) func() (*http.Response, error) {
+	return func() (*http.Response, error) {
+		return &http.Response{StatusCode: code, Body: ioutil.NopCloser(strings.NewReader(""))}, nil
+	}
+}
+
+func networkFailure() (*http.Response, error) {
+	return nil, errors.New("connection reset by peer")
+}
+
+func Test_WithRetry(t *testing.T) {
+	tests := []struct {
+		name        string
+		failures    int
+		failure     func() (*http.Response, error)
+		statusCodes []int
+		expErr      bool
+		expCalls    int
+	}{
+		{name: "no failure", failures: 0, failure: networkFailure, expCalls: 1},
+		{name: "network errors then success", failures: 2, failure: networkFailure, expCalls: 3},
+		{name: "5xx then success", failures: 2, failure: statusFailure(503), expCalls: 3},
+		{name: "attempts exhausted", failures: 5, failure: statusFailure(500), expErr: true, expCalls: 4},
+		{name: "429 then success", failures: 1, failure: statusFailure(429), expCalls: 2},
+		{name: "404 not retried", failures: 1, failure: statusFailure(404), expErr: true, expCalls: 1},
+		{name: "504 not retried", failures: 1, failure: statusFailure(504), expErr: true, expCalls: 1},
+		{name: "custom status codes", failures: 1, failure: statusFailure(504), statusCodes: []int{504}, expCalls: 2},
+		{name: "custom status codes exclude defaults", failures: 1, failure: statusFailure(500), statusCodes: []int{504}, expErr: true, expCalls: 1},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var calls int
+			var delays []time.Duration
+			api := onesecmail.NewAPI(
+				onesecmail.WithHTTPClient(failingClient(test.failures, test.failure, &calls)),
+				onesecmail.WithRetry(onesecmail.RetryConfig{
+					MaxAttempts:          4,
+					InitialDelay:         100 * time.Millisecond,
+					RetryableStatusCodes: test.statusCodes,
+					Sleep: func(ctx context.Context, d time.Duration) error {
+						delays = append(delays, d)
+						return nil
+					},
+				}),
+			)
+			_, err := api.Domains(context.Background())
+			if (err == nil) != !test.expErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if calls != test.expCalls {
+				t.Fatalf("calls expected: %d, got: %d", test.expCalls, calls)
+			}
+			if len(delays) != test.expCalls-1 {
+				t.Fatalf("delays expected: %d, got: %d", test.expCalls-1, len(delays))
+			}
+			for i, d := range delays {
+				if max := 100 * time.Millisecond << i; d < 0 || d > max {
+					t.Fatalf("delay %d expected within [0, %v], got: %v", i, max, d)
+				}
+			}
+		})
+	}
+}
+
+func Test_WithRetry_ContextCancelled(t *testing.T) {
+	var calls int
+	ctx, cancel := context.WithCancel(context.Background())
+	api := onesecmail.NewAPI(
+		onesecmail.WithHTTPClient(failingClient(5, networkFailure, &calls)),
+		onesecmail.WithRetry(onesecmail.RetryConfig{
+			MaxAttempts:  5,
+			InitialDelay: time.Hour,
+			Sleep: func(ctx context.Context, d time.Duration) error {
+				cancel()
+				<-ctx.Done()
+				return ctx.Err()
+			},
+		}),
+	)
+	_, err := api.Domains(ctx)
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("expected context.Canceled, got: %v", err)
+	}
+	if calls != 1 {
+		t.Fatalf("calls expected: 1, got: %d", calls)
+	}
+}
+
+func Test_WithRetry_Backoff(t *testing.T) {
+	var calls int
+	var delays []time.Duration
+	api := onesecmail.NewAPI(
+		onesecmail.WithHTTPClient(failingClient(10, networkFailure, &calls)),
+		onesecmail.WithRetry(onesecmail.RetryConfig{
+			MaxAttempts:  5,
+			InitialDelay: 100 * time.Millisecond,
+			Multiplier:   3,
+			MaxDelay:     time.Second,
+			Sleep: func(ctx context.Context, d time.Duration) error {
+				delays = append(delays, d)
+				return nil
+			},
+		}),
+	)
+	_, err := api.Domains(context.Background())
+	if err == nil || !strings.Contains(err.Error(), "giving up after 5 attempts") {
+		t.Fatalf("expected error with attempt count, got: %v", err)
+	}
+	maxDelays := []time.Duration{100 * time.Millisecond, 300 * time.Millisecond, 900 * time.Millisecond, time.Second}
+	if len(delays) != len(maxDelays) {
+		t.Fatalf("delays expected: %d, got: %d", len(maxDelays), len(delays))
+	}
+	for i, d := range delays {
+		if d < 0 || d > maxDelays[i] {
+			t.Fatalf("delay %d expected within [0, %v], got: %v", i, maxDelays[i], d)
+		}
+	}
+}
+
+func Test_WithRetry_HugeDelay(t *testing.T) {
+	for _, strategy := range []onesecmail.JitterStrategy{onesecmail.NoJitter, onesecmail.FullJitter, onesecmail.EqualJitter} {
+		var delays []time.Duration
+		mailbox, err := onesecmail.NewMailbox("foo", "1secmail.com",
+			onesecmail.WithHTTPClient(&ClientMock{DoFunc: func(req *http.Request) (*http.Response, error) { return networkFailure() }}),
+			onesecmail.WithRetry(onesecmail.RetryConfig{
+				MaxAttempts:  3,
+				InitialDelay: time.Hour,
+				Multiplier:   1e12,
+				Sleep: func(ctx context.Context, d time.Duration) error {
+					delays = append(delays, d)
+					return nil
+				},
+			}),
+			onesecmail.WithJitter(strategy),
+		)
+		if err != nil {
+			t.Fatal("should not error")
+		}
+		if _, err := mailbox.CheckInbox(context.Background()); err == nil {
+			t.Fatal("should error")
+		}
+		if len(delays) != 2 {
+			t.Fatalf("delays expected: 2, got: %d", len(delays))
+		}
+		for _, d := range delays {
+			if d < 0 {
+				t.Fatalf("delay expected to be positive, got: %v", d)
+			}
+		}
+		if strategy == onesecmail.NoJitter && delays[1] != math.MaxInt64-1 {
+			t.Fatalf("delay expected to be clamped, got: %v", delays[1])
+		}
+	}
+}
+
+func Test_WithJitter(t *testing.T) {
+	const initialDelay = 100 * time.Millisecond
+	tests := []struct {
+		name     string
+		strategy onesecmail.JitterStrategy
+		min      time.Duration
+		max      time.Duration
+	}{
+		{name: "no jitter", strategy: onesecmail.NoJitter, min: initialDelay, max: initialDelay},
+		{name: "full jitter", strategy: onesecmail.FullJitter, min: 0, max: initialDelay},
+		{name: "equal jitter", strategy: onesecmail.EqualJitter, min: initialDelay / 2, max: initialDelay},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var delays []time.Duration
+			api := onesecmail.NewAPI(
+				onesecmail.WithHTTPClient(&ClientMock{DoFunc: func(req *http.Request) (*http.Response, error) { return networkFailure() }}),
+				onesecmail.WithRetry(onesecmail.RetryConfig{
+					MaxAttempts:  2,
+					InitialDelay: initialDelay,
+					Sleep: func(ctx context.Context, d time.Duration) error {
+						delays = append(delays, d)
+						return nil
+					},
+				}),
+				onesecmail.WithJitter(test.strategy),
+				onesecmail.WithRand(rand.New(rand.NewSource(1))),
+			)
+			for i := 0; i < 1000; i++ {
+				api.Domains(context.Background())
+			}
+			distinct := make(map[time.Duration]struct{})
+			for _, d := range delays {
+				if d < test.min || d > test.max {
+					t.Fatalf("delay expected within [%v, %v], got: %v", test.min, test.max, d)
+				}
+				distinct[d] = struct{}{}
+			}
+			if test.min != test.max && len(distinct) < 100 {
+				t.Fatalf("delays should be randomized, got %d distinct values", len(distinct))
+			}
+		})
+	}
+}
+
+func Test_WithRand_Seeded(t *testing.T) {
+	sample := func() []time.Duration {
+		var delays []time.Duration
+		api := onesecmail.NewAPI(
+			onesecmail.WithHTTPClient(&ClientMock{DoFunc: func(req *http.Request) (*http.Response, error) { return networkFailure() }}),
+			onesecmail.WithRetry(onesecmail.RetryConfig{
+				MaxAttempts:  4,
+				InitialDelay: time.Second,
+				Sleep: func(ctx context.Context, d time.Duration) error {
+					delays = append(delays, d)
+					return nil
+				},
+			}),
+			onesecmail.WithRand(rand.New(rand.NewSource(42))),
+		)
+		api.Domains(context.Background())
+		return delays
+	}
+	first, second := sample(), sample()
+	for i := range first {
+		if first[i] != second[i] {
+			t.Fatalf("seeded delays should be reproducible, got: %v and %v", first, second)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package onesecmail
+
+import (
+	"context"
+	"sync"
+)
+
+var (
+	defaultAPIOnce sync.Once
+	defaultAPI     API
+)
+
+// getDefaultAPI returns the API used by the package-level functions, which makes
+// requests to the 1secmail API using http.DefaultClient.
+func getDefaultAPI() API {
+	defaultAPIOnce.Do(func() {
+		defaultAPI = NewAPI()
+	})
+	return defaultAPI
+}
+
+// defaultMailbox returns a Mailbox for address, validated as by
+// NewMailboxWithAddress, that uses the default API.
+func defaultMailbox(address string) (Mailbox, error) {
+	mailbox, err := NewMailboxWithAddress(address)
+	if err != nil {
+		return Mailbox{}, err
+	}
+	mailbox.API = getDefaultAPI()
+	return mailbox, nil
+}
+
+// CheckInbox checks the inbox of address with the default API, as
+// Mailbox.CheckInbox does.
+func CheckInbox(ctx context.Context, address string) ([]*Mail, error) {
+	mailbox, err := defaultMailbox(address)
+	if err != nil {
+		return nil, err
+	}
+	return mailbox.CheckInbox(ctx)
+}
+
+// ReadMessage reads the mail with id in the inbox of address with the default API,
+// as Mailbox.ReadMessage does.
+func ReadMessage(ctx context.Context, address string, id int) (*Mail, error) {
+	mailbox, err := defaultMailbox(address)
+	if err != nil {
+		return nil, err
+	}
+	return mailbox.ReadMessage(ctx, id)
+}
+
+// RandomMailbox generates a random mailbox with the default API, as
+// API.RandomMailbox does.
+func RandomMailbox(ctx context.Context) (Mailbox, error) {
+	return getDefaultAPI().RandomMailbox(ctx)
+}
+
+// FetchDomains returns the live list of domains with the default API, as API.Domains
+// does. It does not change the Domains variable; use RefreshDomains for that.
+func FetchDomains(ctx context.Context) ([]string, error) {
+	return getDefaultAPI().Domains(ctx)
+}
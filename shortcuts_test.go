@@ -0,0 +1,76 @@
+package onesecmail_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/z11i/onesecmail"
+)
+
+// roundTripFunc is an http.RoundTripper calling itself.
+type roundTripFunc func(req *http.Request) (*http.Response, error)
+
+func (f roundTripFunc) RoundTrip(req *http.Request) (*http.Response, error) {
+	return f(req)
+}
+
+func Test_Shortcuts(t *testing.T) {
+	var queries []string
+	transport := http.DefaultClient.Transport
+	http.DefaultClient.Transport = roundTripFunc(func(req *http.Request) (*http.Response, error) {
+		if req.URL.Host != "www.1secmail.com" {
+			t.Fatalf("unexpected host: %s", req.URL.Host)
+		}
+		query := req.URL.Query()
+		queries = append(queries, req.URL.RawQuery)
+		body := ""
+		switch query.Get("action") {
+		case "getMessages":
+			body = `[{"id":639,"from":"someone@example.com","subject":"Some subject","date":"2018-06-08 14:33:55"}]`
+		case "readMessage":
+			body = `{"id":639,"from":"someone@example.com","subject":"Some subject","date":"2018-06-08 14:33:55","textBody":"hi"}`
+		case "genRandomMailbox":
+			body = `["random@1secmail.net"]`
+		case "getDomainList":
+			body = `["1secmail.com","1secmail.org"]`
+		}
+		return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader(body))}, nil
+	})
+	defer func() { http.DefaultClient.Transport = transport }()
+	ctx := context.Background()
+
+	mails, err := onesecmail.CheckInbox(ctx, "foo@1secmail.com")
+	if err != nil || len(mails) != 1 || mails[0].ID != 639 {
+		t.Fatalf("1 mail expected, got: %v, %v", mails, err)
+	}
+	mail, err := onesecmail.ReadMessage(ctx, "foo@1secmail.com", 639)
+	if err != nil || mail.TextBody == nil || *mail.TextBody != "hi" {
+		t.Fatalf("full mail expected, got: %+v, %v", mail, err)
+	}
+	mailbox, err := onesecmail.RandomMailbox(ctx)
+	if err != nil || mailbox.Address() != "random@1secmail.net" {
+		t.Fatalf("random mailbox expected, got: %v, %v", mailbox, err)
+	}
+	domains, err := onesecmail.FetchDomains(ctx)
+	if exp := []string{"1secmail.com", "1secmail.org"}; err != nil || !reflect.DeepEqual(domains, exp) {
+		t.Fatalf("domains expected: %v, got: %v, %v", exp, domains, err)
+	}
+	if len(queries) != 4 {
+		t.Fatalf("4 requests expected, got: %v", queries)
+	}
+
+	if _, err := onesecmail.CheckInbox(ctx, "foo@example.com"); !errors.Is(err, onesecmail.ErrInvalidDomain) {
+		t.Fatalf("expected ErrInvalidDomain, got: %v", err)
+	}
+	if _, err := onesecmail.ReadMessage(ctx, "foo", 639); !errors.Is(err, onesecmail.ErrInvalidAddress) {
+		t.Fatalf("expected ErrInvalidAddress, got: %v", err)
+	}
+	if len(queries) != 4 {
+		t.Fatalf("invalid addresses should not be requested, got: %v", queries)
+	}
+}
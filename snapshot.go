@@ -0,0 +1,72 @@
+package onesecmail
+
+import (
+	"context"
+	"time"
+)
+
+// MailboxSnapshot is the inbox of a mailbox at a point in time, as returned by
+// Mailbox.Snapshot. It can be encoded as JSON, e.g. to compare with the inbox in a
+// later run.
+type MailboxSnapshot struct {
+	Messages   []*Mail   `json:"messages"`
+	CapturedAt time.Time `json:"capturedAt"`
+}
+
+// Snapshot checks the inbox of a mailbox, and returns its mails with the time they
+// were checked at.
+func (m Mailbox) Snapshot(ctx context.Context) (MailboxSnapshot, error) {
+	mails, err := m.CheckInbox(ctx)
+	if err != nil {
+		return MailboxSnapshot{}, err
+	}
+	return MailboxSnapshot{Messages: mails, CapturedAt: time.Now()}, nil
+}
+
+// DiffSnapshots compares two snapshots of an inbox by mail ID, and returns the mails
+// of after that are not in before, and the mails of before that are not in after,
+// each in the order of their snapshot.
+func DiffSnapshots(before, after MailboxSnapshot) (added, removed []*Mail) {
+	return missingByID(after.Messages, before.Messages), missingByID(before.Messages, after.Messages)
+}
+
+// Diff checks the inbox of a mailbox, and returns the mails whose ID is not the ID
+// of any of previous, e.g. the mails returned by an earlier check. Mails of previous
+// that are no longer in the inbox are ignored; use DiffSnapshots to find them.
+func (m Mailbox) Diff(ctx context.Context, previous []*Mail) (added []*Mail, err error) {
+	mails, err := m.CheckInbox(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return missingByID(mails, previous), nil
+}
+
+// DedupMessages returns the mails of mails without duplicate IDs, keeping the first
+// mail with each ID, in the order of mails. It is useful to merge the mails of
+// several checks of an inbox.
+func DedupMessages(mails []*Mail) []*Mail {
+	seen := make(map[int]struct{}, len(mails))
+	deduped := make([]*Mail, 0, len(mails))
+	for _, mail := range mails {
+		if _, ok := seen[mail.ID]; !ok {
+			seen[mail.ID] = struct{}{}
+			deduped = append(deduped, mail)
+		}
+	}
+	return deduped
+}
+
+// missingByID returns the mails of mails whose ID is not the ID of any of others.
+func missingByID(mails, others []*Mail) []*Mail {
+	ids := make(map[int]struct{}, len(others))
+	for _, mail := range others {
+		ids[mail.ID] = struct{}{}
+	}
+	var missing []*Mail
+	for _, mail := range mails {
+		if _, ok := ids[mail.ID]; !ok {
+			missing = append(missing, mail)
+		}
+	}
+	return missing
+}
@@ -0,0 +1,162 @@
+package onesecmail_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/z11i/onesecmail"
+)
+
+func Test_Snapshot(t *testing.T) {
+	client, _ := inboxSequenceClient(
+		`[{"id":639,"subject":"first"},{"id":640,"subject":"second"}]`,
+		`[{"id":640,"subject":"second"},{"id":641,"subject":"third"},{"id":642,"subject":"fourth"}]`,
+	)
+	mailbox, err := onesecmail.NewMailbox("foo", "1secmail.com", onesecmail.WithHTTPClient(client))
+	if err != nil {
+		t.Fatal("should not error")
+	}
+	ctx := context.Background()
+
+	start := time.Now()
+	before, err := mailbox.Snapshot(ctx)
+	if err != nil {
+		t.Fatalf("should not error: %v", err)
+	}
+	if len(before.Messages) != 2 || before.CapturedAt.Before(start) {
+		t.Fatalf("unexpected snapshot: %+v", before)
+	}
+
+	// Snapshots survive a round trip through JSON, e.g. when saved to disk.
+	data, err := json.Marshal(before)
+	if err != nil {
+		t.Fatalf("should not error: %v", err)
+	}
+	var loaded onesecmail.MailboxSnapshot
+	if err := json.Unmarshal(data, &loaded); err != nil {
+		t.Fatalf("should not error: %v", err)
+	}
+	if !loaded.CapturedAt.Equal(before.CapturedAt) || !reflect.DeepEqual(mailIDs(loaded.Messages), []int{639, 640}) {
+		t.Fatalf("snapshot expected: %+v, got: %+v", before, loaded)
+	}
+
+	after, err := mailbox.Snapshot(ctx)
+	if err != nil {
+		t.Fatalf("should not error: %v", err)
+	}
+	added, removed := onesecmail.DiffSnapshots(loaded, after)
+	if ids := mailIDs(added); !reflect.DeepEqual(ids, []int{641, 642}) {
+		t.Fatalf("added expected: [641 642], got: %v", ids)
+	}
+	if ids := mailIDs(removed); !reflect.DeepEqual(ids, []int{639}) {
+		t.Fatalf("removed expected: [639], got: %v", ids)
+	}
+
+	added, removed = onesecmail.DiffSnapshots(after, after)
+	if len(added) != 0 || len(removed) != 0 {
+		t.Fatalf("no changes expected, got: %v, %v", mailIDs(added), mailIDs(removed))
+	}
+	added, removed = onesecmail.DiffSnapshots(onesecmail.MailboxSnapshot{}, after)
+	if len(added) != 3 || len(removed) != 0 {
+		t.Fatalf("all mails added expected, got: %v, %v", mailIDs(added), mailIDs(removed))
+	}
+}
+
+func Test_Snapshot_Error(t *testing.T) {
+	client := &ClientMock{DoFunc: func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: 500, Body: io.NopCloser(strings.NewReader(""))}, nil
+	}}
+	mailbox, err := onesecmail.NewMailbox("foo", "1secmail.com", onesecmail.WithHTTPClient(client))
+	if err != nil {
+		t.Fatal("should not error")
+	}
+	if _, err := mailbox.Snapshot(context.Background()); err == nil || !strings.Contains(err.Error(), "check inbox failed") {
+		t.Fatalf("check inbox error expected, got: %v", err)
+	}
+}
+
+func Test_Diff(t *testing.T) {
+	client, _ := inboxSequenceClient(
+		`[]`,
+		`[{"id":639,"subject":"first"},{"id":640,"subject":"second"}]`,
+		`[{"id":639,"subject":"first"},{"id":640,"subject":"second"}]`,
+		`[{"id":640,"subject":"second"},{"id":641,"subject":"third"}]`,
+	)
+	mailbox, err := onesecmail.NewMailbox("foo", "1secmail.com", onesecmail.WithHTTPClient(client))
+	if err != nil {
+		t.Fatal("should not error")
+	}
+	ctx := context.Background()
+
+	var previous []*onesecmail.Mail
+	tests := []struct {
+		name     string
+		expAdded []int
+	}{
+		{name: "empty inbox", expAdded: []int{}},
+		{name: "empty to non-empty", expAdded: []int{639, 640}},
+		{name: "unchanged", expAdded: []int{}},
+		{name: "removed and added", expAdded: []int{641}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			added, err := mailbox.Diff(ctx, previous)
+			if err != nil {
+				t.Fatalf("should not error: %v", err)
+			}
+			if ids := mailIDs(added); !reflect.DeepEqual(ids, test.expAdded) {
+				t.Fatalf("added expected: %v, got: %v", test.expAdded, ids)
+			}
+			previous = append(previous, added...)
+		})
+	}
+}
+
+func Test_Diff_Error(t *testing.T) {
+	client := &ClientMock{DoFunc: func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: 500, Body: io.NopCloser(strings.NewReader(""))}, nil
+	}}
+	mailbox, err := onesecmail.NewMailbox("foo", "1secmail.com", onesecmail.WithHTTPClient(client))
+	if err != nil {
+		t.Fatal("should not error")
+	}
+	if _, err := mailbox.Diff(context.Background(), nil); err == nil || !strings.Contains(err.Error(), "check inbox failed") {
+		t.Fatalf("check inbox error expected, got: %v", err)
+	}
+}
+
+func Test_DedupMessages(t *testing.T) {
+	first := &onesecmail.Mail{ID: 639, Subject: "first"}
+	tests := []struct {
+		name     string
+		mails    []*onesecmail.Mail
+		expIDs   []int
+		expFirst *onesecmail.Mail
+	}{
+		{name: "nil", expIDs: []int{}},
+		{name: "no duplicates", mails: []*onesecmail.Mail{{ID: 641}, {ID: 639}, {ID: 640}}, expIDs: []int{641, 639, 640}},
+		{
+			name:     "duplicates",
+			mails:    []*onesecmail.Mail{{ID: 640}, first, {ID: 640}, {ID: 641}, {ID: 639, Subject: "again"}},
+			expIDs:   []int{640, 639, 641},
+			expFirst: first,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got := onesecmail.DedupMessages(test.mails)
+			if ids := mailIDs(got); !reflect.DeepEqual(ids, test.expIDs) {
+				t.Fatalf("IDs expected: %v, got: %v", test.expIDs, ids)
+			}
+			if test.expFirst != nil && got[1] != test.expFirst {
+				t.Fatalf("first mail with ID 639 expected, got: %v", got[1])
+			}
+		})
+	}
+}
@@ -0,0 +1,49 @@
+package onesecmail
+
+import (
+	"sort"
+	"time"
+)
+
+// SortByDate sorts mails in place by date, oldest first if ascending, or newest
+// first otherwise. Mails whose date cannot be parsed are sorted after the others.
+// Mails with the same date, or whose dates cannot be parsed, are sorted by ID in the
+// same direction.
+func SortByDate(mails []*Mail, ascending bool) {
+	type dated struct {
+		mail *Mail
+		date time.Time
+		ok   bool
+	}
+	sorted := make([]dated, len(mails))
+	for i, mail := range mails {
+		date, ok := mail.date()
+		sorted[i] = dated{mail: mail, date: date, ok: ok}
+	}
+	sort.SliceStable(sorted, func(i, j int) bool {
+		if sorted[i].ok != sorted[j].ok {
+			return sorted[i].ok
+		}
+		if !ascending {
+			i, j = j, i
+		}
+		if sorted[i].ok && !sorted[i].date.Equal(sorted[j].date) {
+			return sorted[i].date.Before(sorted[j].date)
+		}
+		return sorted[i].mail.ID < sorted[j].mail.ID
+	})
+	for i := range sorted {
+		mails[i] = sorted[i].mail
+	}
+}
+
+// SortByID sorts mails in place by ID, lowest first if ascending, or highest first
+// otherwise.
+func SortByID(mails []*Mail, ascending bool) {
+	sort.SliceStable(mails, func(i, j int) bool {
+		if !ascending {
+			i, j = j, i
+		}
+		return mails[i].ID < mails[j].ID
+	})
+}
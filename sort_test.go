@@ -0,0 +1,94 @@
+package onesecmail_test
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/z11i/onesecmail"
+)
+
+func mailIDs(mails []*onesecmail.Mail) []int {
+	ids := make([]int, len(mails))
+	for i, mail := range mails {
+		ids[i] = mail.ID
+	}
+	return ids
+}
+
+func Test_SortByDate(t *testing.T) {
+	tests := []struct {
+		name      string
+		ascending bool
+		expIDs    []int
+	}{
+		{name: "ascending", ascending: true, expIDs: []int{3, 1, 2, 4, 5}},
+		{name: "descending", ascending: false, expIDs: []int{5, 4, 2, 1, 3}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mails := []*onesecmail.Mail{
+				{ID: 4, Date: "2018-06-08 14:40:55"},
+				{ID: 2, Date: "2018-06-08 14:33:55"},
+				{ID: 1, Date: "2018-06-08 14:33:55"},
+				{ID: 5, Date: "2018-06-09 08:00:00"},
+				{ID: 3, Date: "2018-06-07 23:59:59"},
+			}
+			onesecmail.SortByDate(mails, test.ascending)
+			if ids := mailIDs(mails); !reflect.DeepEqual(ids, test.expIDs) {
+				t.Fatalf("order expected: %v, got: %v", test.expIDs, ids)
+			}
+		})
+	}
+}
+
+func Test_SortByDate_InvalidDates(t *testing.T) {
+	mails := []*onesecmail.Mail{{ID: 2, Date: "yesterday"}, {ID: 1, Date: ""}}
+	onesecmail.SortByDate(mails, false)
+	if ids := mailIDs(mails); !reflect.DeepEqual(ids, []int{2, 1}) {
+		t.Fatalf("order expected by ID: [2 1], got: %v", ids)
+	}
+}
+
+func Test_SortByDate_MixedDates(t *testing.T) {
+	tests := []struct {
+		name      string
+		ascending bool
+		expIDs    []int
+	}{
+		{name: "ascending", ascending: true, expIDs: []int{5, 3, 1, 2, 4, 6}},
+		{name: "descending", ascending: false, expIDs: []int{1, 3, 5, 6, 4, 2}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mails := []*onesecmail.Mail{
+				{ID: 6, Date: "yesterday"},
+				{ID: 3, Date: "2018-06-08 14:33:55"},
+				{ID: 2},
+				{ID: 1, ParsedDate: time.Date(2018, 6, 9, 8, 0, 0, 0, time.UTC)},
+				{ID: 4, Date: "not a date"},
+				{ID: 5, Date: "2018-06-07 23:59:59"},
+			}
+			// The order does not depend on the order of the input.
+			for i := 0; i < len(mails); i++ {
+				rotated := append(append([]*onesecmail.Mail(nil), mails[i:]...), mails[:i]...)
+				onesecmail.SortByDate(rotated, test.ascending)
+				if ids := mailIDs(rotated); !reflect.DeepEqual(ids, test.expIDs) {
+					t.Fatalf("order expected: %v, got: %v", test.expIDs, ids)
+				}
+			}
+		})
+	}
+}
+
+func Test_SortByID(t *testing.T) {
+	mails := []*onesecmail.Mail{{ID: 640}, {ID: 639}, {ID: 641}}
+	onesecmail.SortByID(mails, true)
+	if ids := mailIDs(mails); !reflect.DeepEqual(ids, []int{639, 640, 641}) {
+		t.Fatalf("ascending order expected, got: %v", ids)
+	}
+	onesecmail.SortByID(mails, false)
+	if ids := mailIDs(mails); !reflect.DeepEqual(ids, []int{641, 640, 639}) {
+		t.Fatalf("descending order expected, got: %v", ids)
+	}
+}
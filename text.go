@@ -0,0 +1,116 @@
+package onesecmail
+
+import (
+	"regexp"
+	"strings"
+
+	"golang.org/x/net/html"
+)
+
+var (
+	spaceRe      = regexp.MustCompile(`[ \t\r\f\v\x{00a0}]+`)
+	blankLinesRe = regexp.MustCompile(`\n{3,}`)
+)
+
+// blockElements are the elements that break lines in the text of HTMLToText.
+var blockElements = map[string]bool{
+	"p": true, "div": true, "br": true, "li": true, "tr": true, "ul": true, "ol": true, "table": true,
+	"h1": true, "h2": true, "h3": true, "h4": true, "h5": true, "h6": true,
+	"blockquote": true, "hr": true, "section": true, "article": true, "header": true, "footer": true,
+}
+
+// invisibleElements are the elements whose content is left out of the text of HTMLToText.
+var invisibleElements = map[string]bool{"script": true, "style": true, "head": true, "noscript": true, "template": true}
+
+// PlainText returns a plain text rendering of a mail's body. It returns the TextBody
+// if it is set, otherwise the HTMLBody, or else the Body, stripped of HTML tags.
+func (m *Mail) PlainText() string {
+	if m.TextBody != nil {
+		return *m.TextBody
+	}
+	if m.HTMLBody != nil {
+		return HTMLToText(*m.HTMLBody)
+	}
+	if m.Body != nil {
+		return HTMLToText(*m.Body)
+	}
+	return ""
+}
+
+// BestBody returns the body of a mail best suited for reading as is. It returns the
+// TextBody if it is set and not empty, otherwise the raw HTMLBody, or else the Body.
+// It returns an empty string if no body is set.
+func (m *Mail) BestBody() string {
+	switch {
+	case m.TextBody != nil && *m.TextBody != "":
+		return *m.TextBody
+	case m.HTMLBody != nil:
+		return *m.HTMLBody
+	case m.Body != nil:
+		return *m.Body
+	}
+	return ""
+}
+
+// textBodies returns the bodies of a mail that are set, in the order TextBody,
+// HTMLBody and Body, with the HTML ones converted by HTMLToText.
+func (m *Mail) textBodies() []string {
+	var bodies []string
+	if m.TextBody != nil {
+		bodies = append(bodies, *m.TextBody)
+	}
+	if m.HTMLBody != nil {
+		bodies = append(bodies, HTMLToText(*m.HTMLBody))
+	}
+	if m.Body != nil {
+		bodies = append(bodies, HTMLToText(*m.Body))
+	}
+	return bodies
+}
+
+// HTMLToText returns the text of an HTML document, as it would be read. Scripts,
+// styles and the head of the document are left out, entities are decoded, and
+// whitespace is collapsed, with lines broken at line breaks and block elements such
+// as p, div and li. Malformed HTML is converted as far as it can be.
+func HTMLToText(s string) string {
+	var b strings.Builder
+	z := html.NewTokenizer(strings.NewReader(s))
+	invisible := 0
+	for {
+		tt := z.Next()
+		if tt == html.ErrorToken {
+			break
+		}
+		switch tt {
+		case html.TextToken:
+			if invisible == 0 {
+				b.WriteString(strings.ReplaceAll(string(z.Text()), "\n", " "))
+			}
+		case html.StartTagToken, html.SelfClosingTagToken, html.EndTagToken:
+			name, _ := z.TagName()
+			tag := string(name)
+			switch {
+			case tag == "body":
+				// The head may not be closed before the body.
+				invisible = 0
+			case invisibleElements[tag] && tt == html.StartTagToken:
+				invisible++
+			case invisibleElements[tag] && tt == html.EndTagToken && invisible > 0:
+				invisible--
+			}
+			// A list item or a table row only breaks the line before it, so that
+			// consecutive ones are not separated by blank lines.
+			if blockElements[tag] && !(tt == html.EndTagToken && (tag == "li" || tag == "tr")) {
+				b.WriteByte('\n')
+			}
+		}
+	}
+
+	lines := strings.Split(b.String(), "\n")
+	for i, line := range lines {
+		lines[i] = strings.TrimSpace(spaceRe.ReplaceAllString(line, " "))
+	}
+	text := strings.Join(lines, "\n")
+	text = blankLinesRe.ReplaceAllString(text, "\n\n")
+	return strings.TrimSpace(text)
+}
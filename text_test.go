@@ -0,0 +1,121 @@
+package onesecmail_test
+
+import (
+	"testing"
+
+	"github.com/z11i/onesecmail"
+)
+
+func Test_PlainText(t *testing.T) {
+	tests := []struct {
+		name string
+		mail onesecmail.Mail
+		exp  string
+	}{
+		{name: "no body", mail: onesecmail.Mail{}, exp: ""},
+		{name: "text body preferred", mail: onesecmail.Mail{TextBody: strPtr("plain"), HTMLBody: strPtr("<b>html</b>")}, exp: "plain"},
+		{
+			name: "html body",
+			mail: onesecmail.Mail{HTMLBody: strPtr("<html><head><title>t</title><style>p{color:red}</style></head><body><p>Hello&nbsp;<b>World</b> &amp; friends</p><p>Line   one<br>Line\n two</p><script>alert(1)</script></body></html>")},
+			exp:  "Hello World & friends\n\nLine one\nLine two",
+		},
+		{name: "falls back to body", mail: onesecmail.Mail{Body: strPtr("<div>Hi &lt;there&gt;</div>")}, exp: "Hi <there>"},
+		{name: "malformed html", mail: onesecmail.Mail{HTMLBody: strPtr("<p>Unclosed <b>bold <a href=")}, exp: "Unclosed bold"},
+		{name: "less than sign", mail: onesecmail.Mail{HTMLBody: strPtr("<p>1 < 2</p>")}, exp: "1 < 2"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.mail.PlainText(); got != test.exp {
+				t.Fatalf("expected: %q, got: %q", test.exp, got)
+			}
+		})
+	}
+}
+
+func Test_BestBody(t *testing.T) {
+	tests := []struct {
+		name string
+		mail onesecmail.Mail
+		exp  string
+	}{
+		{name: "no body", mail: onesecmail.Mail{}, exp: ""},
+		{name: "text only", mail: onesecmail.Mail{TextBody: strPtr("plain")}, exp: "plain"},
+		{name: "html only", mail: onesecmail.Mail{HTMLBody: strPtr("<b>html</b>")}, exp: "<b>html</b>"},
+		{name: "both set", mail: onesecmail.Mail{TextBody: strPtr("plain"), HTMLBody: strPtr("<b>html</b>")}, exp: "plain"},
+		{name: "empty text", mail: onesecmail.Mail{TextBody: strPtr(""), HTMLBody: strPtr("<b>html</b>")}, exp: "<b>html</b>"},
+		{name: "empty text without html", mail: onesecmail.Mail{TextBody: strPtr("")}, exp: ""},
+		{name: "falls back to body", mail: onesecmail.Mail{Body: strPtr("body")}, exp: "body"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := test.mail.BestBody(); got != test.exp {
+				t.Fatalf("expected: %q, got: %q", test.exp, got)
+			}
+		})
+	}
+}
+
+func Test_HTMLToText(t *testing.T) {
+	tests := []struct {
+		name string
+		html string
+		exp  string
+	}{
+		{name: "empty", html: "", exp: ""},
+		{name: "plain text", html: "just text", exp: "just text"},
+		{name: "entities", html: "Tom &amp; Jerry &lt;3 &#8212; &quot;hi&quot;", exp: `Tom & Jerry <3 — "hi"`},
+		{name: "script and style", html: "<style>p{}</style><p>shown</p><script>var s = '<p>hidden</p>';</script>", exp: "shown"},
+		{name: "list items", html: "<ul><li>one</li><li>two</li></ul>", exp: "one\ntwo"},
+		{name: "unclosed head", html: "<html><head><meta charset=utf-8><body><p>Body</p>", exp: "Body"},
+		{name: "comment", html: "a<!-- <p>hidden</p> -->b", exp: "ab"},
+		{name: "unterminated tag", html: "text <div class=", exp: "text"},
+		{name: "stray closing tags", html: "</div></p>text</b>", exp: "text"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			if got := onesecmail.HTMLToText(test.html); got != test.exp {
+				t.Fatalf("expected: %q, got: %q", test.exp, got)
+			}
+		})
+	}
+}
+
+func Test_HTMLToText_EmailTemplate(t *testing.T) {
+	template := `<!DOCTYPE html>
+<html lang="en">
+<head>
+  <meta charset="utf-8">
+  <title>Confirm your email</title>
+  <style type="text/css">
+    body { margin: 0; padding: 0; }
+    .button { background: #0066ff; }
+  </style>
+</head>
+<body>
+  <table role="presentation" width="100%" cellpadding="0" cellspacing="0">
+    <tr>
+      <td align="center">
+        <h1>Welcome to Example&nbsp;App!</h1>
+        <p>Hi there,</p>
+        <p>Thanks for signing up. Your verification code is
+           <strong>482913</strong>.
+        </p>
+        <div><a class="button" href="https://example.com/verify?token=abc">Confirm email</a></div>
+        <p style="color:#999">If you didn&#39;t sign up, ignore this email.<br>
+           &copy; 2024 Example Inc.</p>
+      </td>
+    </tr>
+  </table>
+  <script>trackOpen();</script>
+</body>
+</html>`
+	exp := "Welcome to Example App!\n\n" +
+		"Hi there,\n\n" +
+		"Thanks for signing up. Your verification code is 482913.\n\n" +
+		"Confirm email\n\n" +
+		"If you didn't sign up, ignore this email.\n" +
+		"© 2024 Example Inc."
+	if got := onesecmail.HTMLToText(template); got != exp {
+		t.Fatalf("expected:\n%s\ngot:\n%s", exp, got)
+	}
+}
@@ -0,0 +1,59 @@
+package onesecmail
+
+import (
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+// tracerName is the name of the tracer that traces requests to the API.
+const tracerName = "github.com/z11i/onesecmail"
+
+// WithTracerProvider sets the OpenTelemetry TracerProvider used to trace requests.
+// Each request is traced in a span named "onesecmail." followed by its action, e.g.
+// "onesecmail.getMessages", and the trace context is propagated in the request
+// headers with the global propagator. If it is not set, or tp is nil, the no-op
+// tracer is used.
+func WithTracerProvider(tp trace.TracerProvider) Option {
+	return func(cfg *config) {
+		cfg.tracerProvider = tp
+	}
+}
+
+// startSpan starts the span of a request for action, and returns the request with
+// the context of the span, and its trace context in the headers.
+func (a API) startSpan(req *http.Request, action string) (*http.Request, trace.Span) {
+	tp := a.cfg.tracerProvider
+	if tp == nil {
+		tp = noop.NewTracerProvider()
+	}
+	ctx, span := tp.Tracer(tracerName).Start(req.Context(), "onesecmail."+action,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("http.method", req.Method),
+			attribute.String("http.url", req.URL.String()),
+			attribute.String("onesecmail.action", action),
+		))
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+	return req.WithContext(ctx), span
+}
+
+// endSpan ends the span of a request, with the status code of the response, or the
+// error if the request failed.
+func endSpan(span trace.Span, resp *http.Response, err error) {
+	defer span.End()
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return
+	}
+	span.SetAttributes(attribute.Int("http.status_code", resp.StatusCode))
+	if resp.StatusCode != http.StatusOK {
+		span.SetStatus(codes.Error, http.StatusText(resp.StatusCode))
+	}
+}
@@ -0,0 +1,95 @@
+package onesecmail_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"github.com/z11i/onesecmail"
+)
+
+func Test_WithTracerProvider(t *testing.T) {
+	propagator := otel.GetTextMapPropagator()
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer otel.SetTextMapPropagator(propagator)
+
+	tests := []struct {
+		name       string
+		respCode   int
+		respErr    error
+		expStatus  codes.Code
+		expCodeSet bool
+	}{
+		{name: "success", respCode: 200, expStatus: codes.Unset, expCodeSet: true},
+		{name: "server error", respCode: 500, expStatus: codes.Error, expCodeSet: true},
+		{name: "network error", respErr: errors.New("connection refused"), expStatus: codes.Error},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			recorder := tracetest.NewSpanRecorder()
+			tp := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+			var traceparent string
+			client := &ClientMock{DoFunc: func(req *http.Request) (*http.Response, error) {
+				traceparent = req.Header.Get("Traceparent")
+				if test.respErr != nil {
+					return nil, test.respErr
+				}
+				return &http.Response{StatusCode: test.respCode, Body: io.NopCloser(strings.NewReader("[]"))}, nil
+			}}
+			mailbox, err := onesecmail.NewMailbox("foo", "1secmail.com",
+				onesecmail.WithHTTPClient(client), onesecmail.WithTracerProvider(tp))
+			if err != nil {
+				t.Fatal("should not error")
+			}
+			mailbox.CheckInbox(context.Background())
+
+			spans := recorder.Ended()
+			if len(spans) != 1 {
+				t.Fatalf("1 span expected, got: %d", len(spans))
+			}
+			span := spans[0]
+			if span.Name() != "onesecmail.getMessages" {
+				t.Fatalf("span name expected: onesecmail.getMessages, got: %s", span.Name())
+			}
+			if span.Status().Code != test.expStatus {
+				t.Fatalf("span status expected: %v, got: %v", test.expStatus, span.Status())
+			}
+			attrs := make(map[attribute.Key]attribute.Value)
+			for _, attr := range span.Attributes() {
+				attrs[attr.Key] = attr.Value
+			}
+			if attrs["http.method"].AsString() != "GET" || attrs["onesecmail.action"].AsString() != "getMessages" ||
+				!strings.Contains(attrs["http.url"].AsString(), "action=getMessages") {
+				t.Fatalf("unexpected attributes: %v", span.Attributes())
+			}
+			if code, ok := attrs["http.status_code"]; ok != test.expCodeSet || (ok && code.AsInt64() != int64(test.respCode)) {
+				t.Fatalf("status code attribute expected: %d, got: %v", test.respCode, code)
+			}
+			if exp := span.SpanContext().TraceID().String(); !strings.Contains(traceparent, exp) {
+				t.Fatalf("trace context of %s expected in headers, got: %q", exp, traceparent)
+			}
+		})
+	}
+}
+
+func Test_WithTracerProvider_Unset(t *testing.T) {
+	client := &ClientMock{DoFunc: func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: 200, Body: io.NopCloser(strings.NewReader("[]"))}, nil
+	}}
+	for _, opt := range []onesecmail.Option{nil, onesecmail.WithTracerProvider(nil)} {
+		api := onesecmail.NewAPI(onesecmail.WithHTTPClient(client), opt)
+		if _, err := api.Domains(context.Background()); err != nil {
+			t.Fatalf("should not error: %v", err)
+		}
+	}
+}
@@ -0,0 +1,138 @@
+package onesecmail
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+)
+
+// defaultPollInterval is the interval used for polling the inbox when none is given.
+const defaultPollInterval = 5 * time.Second
+
+// ErrWaitTimeout is returned when the context is done before a wanted mail arrives.
+// The returned error also wraps ctx.Err().
+var ErrWaitTimeout = errors.New("wait for message timed out")
+
+// WaitForMessage polls the inbox of a mailbox every interval, and returns the first
+// mail that matches predicate. A nil predicate matches any mail. It blocks until a
+// matching mail arrives, or until ctx is cancelled or its deadline is exceeded, in
+// which case ErrWaitTimeout is returned. Errors from checking the inbox are ignored
+// and the inbox is checked again on the next poll. If interval is zero or negative,
+// a default of 5 seconds is used.
+func (m Mailbox) WaitForMessage(ctx context.Context, predicate func(*Mail) bool, interval time.Duration) (*Mail, error) {
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		mails, err := m.CheckInbox(ctx)
+		if err == nil {
+			for _, mail := range mails {
+				if predicate == nil || predicate(mail) {
+					return mail, nil
+				}
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("%w: %w", ErrWaitTimeout, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
+
+// WaitForMessageFrom waits for a mail whose sender contains senderSubstring, e.g.
+// "noreply" or "example.com", ignoring case. It polls like WaitForMessage, and
+// returns the full mail read with ReadMessage.
+func (m Mailbox) WaitForMessageFrom(ctx context.Context, senderSubstring string, interval time.Duration) (*Mail, error) {
+	if senderSubstring == "" {
+		return nil, errors.New("wait for message failed: empty sender")
+	}
+	senderSubstring = strings.ToLower(senderSubstring)
+	mail, err := m.WaitForMessage(ctx, func(mail *Mail) bool {
+		return strings.Contains(strings.ToLower(mail.From), senderSubstring)
+	}, interval)
+	if err != nil {
+		return nil, err
+	}
+	return m.ReadMessage(ctx, mail.ID)
+}
+
+// WaitForMessageWithSubject waits for a mail whose subject matches pattern. It polls
+// like WaitForMessage, and returns the full mail read with ReadMessage.
+func (m Mailbox) WaitForMessageWithSubject(ctx context.Context, pattern *regexp.Regexp, interval time.Duration) (*Mail, error) {
+	if pattern == nil {
+		return nil, errors.New("wait for message failed: nil subject pattern")
+	}
+	mail, err := m.WaitForMessage(ctx, func(mail *Mail) bool {
+		return pattern.MatchString(mail.Subject)
+	}, interval)
+	if err != nil {
+		return nil, err
+	}
+	return m.ReadMessage(ctx, mail.ID)
+}
+
+// WaitForSubject waits for a mail whose subject contains substr, e.g. "Confirm your
+// email", ignoring case. It polls like WaitForMessage, and returns the full mail read
+// with ReadMessage.
+func (m Mailbox) WaitForSubject(ctx context.Context, substr string, interval time.Duration) (*Mail, error) {
+	if substr == "" {
+		return nil, errors.New("wait for message failed: empty subject")
+	}
+	substr = strings.ToLower(substr)
+	mail, err := m.WaitForMessage(ctx, func(mail *Mail) bool {
+		return strings.Contains(strings.ToLower(mail.Subject), substr)
+	}, interval)
+	if err != nil {
+		return nil, err
+	}
+	return m.ReadMessage(ctx, mail.ID)
+}
+
+// WaitForCode polls the inbox of a mailbox every interval, reads each mail it has not
+// seen before, and returns the first code in it that matches pattern, as found by
+// ExtractCodeWithPattern. A nil pattern finds one-time passwords of 4 to 8 digits,
+// as ExtractOTP does. Mails that cannot be read are read again on the next poll. It
+// blocks until a code is found, or until ctx is done, in which case ErrWaitTimeout
+// is returned. If interval is zero or negative, a default of 5 seconds is used.
+func (m Mailbox) WaitForCode(ctx context.Context, pattern *regexp.Regexp, interval time.Duration) (string, error) {
+	if pattern == nil {
+		pattern = defaultOTPPattern
+	}
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	seen := make(map[int]struct{})
+	for {
+		mails, err := m.CheckInbox(ctx)
+		if err == nil {
+			for _, summary := range mails {
+				if _, ok := seen[summary.ID]; ok {
+					continue
+				}
+				mail, err := m.ReadMessage(ctx, summary.ID)
+				if err != nil {
+					continue
+				}
+				seen[summary.ID] = struct{}{}
+				if code, err := mail.ExtractCodeWithPattern(pattern); err == nil {
+					return code, nil
+				}
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return "", fmt.Errorf("%w: %w", ErrWaitTimeout, ctx.Err())
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,303 @@
+package onesecmail_test
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/z11i/onesecmail"
+)
+
+// inboxSequenceClient returns a client that responds with each of bodies in turn,
+// repeating the last one once they are exhausted.
+func inboxSequenceClient(bodies ...string) (*ClientMock, *int32) {
+	var calls int32
+	return &ClientMock{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			if err := req.Context().Err(); err != nil {
+				return nil, err
+			}
+			n := int(atomic.AddInt32(&calls, 1)) - 1
+			if n >= len(bodies) {
+				n = len(bodies) - 1
+			}
+			return &http.Response{StatusCode: 200, Body: ioutil.NopCloser(strings.NewReader(bodies[n]))}, nil
+		},
+	}, &calls
+}
+
+func Test_WaitForMessage(t *testing.T) {
+	t.Run("message arrives", func(t *testing.T) {
+		client, calls := inboxSequenceClient(`[]`, `[]`, `[{"id":639,"from":"someone@example.com","subject":"Some subject","date":"2018-06-08 14:33:55"}]`)
+		mailbox, err := onesecmail.NewMailbox("foo", "1secmail.org", onesecmail.WithHTTPClient(client))
+		if err != nil {
+			t.Fatal("should not error")
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		mail, err := mailbox.WaitForMessage(ctx, nil, time.Millisecond)
+		if err != nil {
+			t.Fatalf("should not error: %v", err)
+		}
+		if mail.ID != 639 {
+			t.Fatalf("mail ID expected: 639, got: %d", mail.ID)
+		}
+		if got := atomic.LoadInt32(calls); got != 3 {
+			t.Fatalf("calls expected: 3, got: %d", got)
+		}
+	})
+	t.Run("context deadline", func(t *testing.T) {
+		client, _ := inboxSequenceClient(`[]`)
+		mailbox, err := onesecmail.NewMailbox("foo", "1secmail.org", onesecmail.WithHTTPClient(client))
+		if err != nil {
+			t.Fatal("should not error")
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+		_, err = mailbox.WaitForMessage(ctx, nil, time.Millisecond)
+		if !errors.Is(err, onesecmail.ErrWaitTimeout) || !errors.Is(err, context.DeadlineExceeded) {
+			t.Fatalf("expected ErrWaitTimeout wrapping context.DeadlineExceeded, got: %v", err)
+		}
+	})
+	t.Run("predicate skips non-matching mails", func(t *testing.T) {
+		client, calls := inboxSequenceClient(
+			`[{"id":639,"from":"someone@example.com","subject":"Welcome","date":"2018-06-08 14:33:55"}]`,
+			`[{"id":639,"from":"someone@example.com","subject":"Welcome","date":"2018-06-08 14:33:55"},{"id":640,"from":"noreply@example.com","subject":"Your code","date":"2018-06-08 14:40:55"}]`,
+		)
+		mailbox, err := onesecmail.NewMailbox("foo", "1secmail.org", onesecmail.WithHTTPClient(client))
+		if err != nil {
+			t.Fatal("should not error")
+		}
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		mail, err := mailbox.WaitForMessage(ctx, func(m *onesecmail.Mail) bool {
+			return strings.Contains(m.Subject, "code")
+		}, time.Millisecond)
+		if err != nil {
+			t.Fatalf("should not error: %v", err)
+		}
+		if mail.ID != 640 {
+			t.Fatalf("mail ID expected: 640, got: %d", mail.ID)
+		}
+		if got := atomic.LoadInt32(calls); got != 2 {
+			t.Fatalf("calls expected: 2, got: %d", got)
+		}
+	})
+	t.Run("cancelled while waiting with default interval", func(t *testing.T) {
+		client, _ := inboxSequenceClient(`[]`)
+		mailbox, err := onesecmail.NewMailbox("foo", "1secmail.org", onesecmail.WithHTTPClient(client))
+		if err != nil {
+			t.Fatal("should not error")
+		}
+		ctx, cancel := context.WithCancel(context.Background())
+		time.AfterFunc(10*time.Millisecond, cancel)
+		start := time.Now()
+		_, err = mailbox.WaitForMessage(ctx, nil, 0)
+		if !errors.Is(err, context.Canceled) {
+			t.Fatalf("expected context.Canceled, got: %v", err)
+		}
+		if time.Since(start) > time.Second {
+			t.Fatal("should react to cancellation without waiting for the next poll")
+		}
+	})
+}
+
+// readMessageClient returns a client that responds to getMessages with inbox, and to
+// readMessage with a full mail for the requested ID.
+func readMessageClient(inbox string) *ClientMock {
+	return &ClientMock{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			if err := req.Context().Err(); err != nil {
+				return nil, err
+			}
+			query := req.URL.Query()
+			body := inbox
+			if query.Get("action") == "readMessage" {
+				body = `{"id":` + query.Get("id") + `,"from":"someone@example.com","subject":"Some subject","date":"2018-06-08 14:33:55","textBody":"Your code is 123456"}`
+			}
+			return &http.Response{StatusCode: 200, Body: ioutil.NopCloser(strings.NewReader(body))}, nil
+		},
+	}
+}
+
+func Test_WaitForMessageFrom(t *testing.T) {
+	inbox := `[{"id":639,"from":"someone@example.com","subject":"Welcome","date":"2018-06-08 14:33:55"},{"id":640,"from":"NoReply@Service.io","subject":"Your code","date":"2018-06-08 14:40:55"}]`
+	tests := []struct {
+		name   string
+		sender string
+		expID  int
+		expErr bool
+	}{
+		{name: "partial address", sender: "noreply", expID: 640},
+		{name: "domain ignoring case", sender: "SERVICE.IO", expID: 640},
+		{name: "no match", sender: "nobody", expErr: true},
+		{name: "empty sender", sender: "", expErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mailbox, err := onesecmail.NewMailbox("foo", "1secmail.org", onesecmail.WithHTTPClient(readMessageClient(inbox)))
+			if err != nil {
+				t.Fatal("should not error")
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+			defer cancel()
+			mail, err := mailbox.WaitForMessageFrom(ctx, test.sender, time.Millisecond)
+			if (err == nil) != !test.expErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err != nil {
+				return
+			}
+			if mail.ID != test.expID {
+				t.Fatalf("mail ID expected: %d, got: %d", test.expID, mail.ID)
+			}
+			if mail.TextBody == nil {
+				t.Fatal("full mail should be read")
+			}
+		})
+	}
+}
+
+func Test_WaitForSubject(t *testing.T) {
+	inbox := `[{"id":639,"from":"someone@example.com","subject":"Welcome","date":"2018-06-08 14:33:55"},{"id":640,"from":"noreply@example.com","subject":"Please Confirm Your Email","date":"2018-06-08 14:40:55"}]`
+	tests := []struct {
+		name    string
+		subject string
+		expID   int
+		expErr  bool
+	}{
+		{name: "exact phrase", subject: "Confirm Your Email", expID: 640},
+		{name: "ignoring case", subject: "confirm your email", expID: 640},
+		{name: "no match", subject: "Invoice", expErr: true},
+		{name: "empty subject", subject: "", expErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mailbox, err := onesecmail.NewMailbox("foo", "1secmail.org", onesecmail.WithHTTPClient(readMessageClient(inbox)))
+			if err != nil {
+				t.Fatal("should not error")
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+			defer cancel()
+			mail, err := mailbox.WaitForSubject(ctx, test.subject, time.Millisecond)
+			if (err == nil) != !test.expErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err != nil {
+				return
+			}
+			if mail.ID != test.expID {
+				t.Fatalf("mail ID expected: %d, got: %d", test.expID, mail.ID)
+			}
+			if mail.TextBody == nil {
+				t.Fatal("full mail should be read")
+			}
+		})
+	}
+}
+
+func Test_WaitForMessageWithSubject(t *testing.T) {
+	inbox := `[{"id":639,"from":"someone@example.com","subject":"Welcome","date":"2018-06-08 14:33:55"},{"id":640,"from":"noreply@example.com","subject":"Your verification code is 123456","date":"2018-06-08 14:40:55"}]`
+	tests := []struct {
+		name    string
+		pattern *regexp.Regexp
+		expID   int
+		expErr  bool
+	}{
+		{name: "match", pattern: regexp.MustCompile(`verification code is \d{6}`), expID: 640},
+		{name: "no match", pattern: regexp.MustCompile(`^Invoice`), expErr: true},
+		{name: "nil pattern", pattern: nil, expErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mailbox, err := onesecmail.NewMailbox("foo", "1secmail.org", onesecmail.WithHTTPClient(readMessageClient(inbox)))
+			if err != nil {
+				t.Fatal("should not error")
+			}
+			ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+			defer cancel()
+			mail, err := mailbox.WaitForMessageWithSubject(ctx, test.pattern, time.Millisecond)
+			if (err == nil) != !test.expErr {
+				t.Fatalf("unexpected error: %v", err)
+			}
+			if err != nil {
+				return
+			}
+			if mail.ID != test.expID {
+				t.Fatalf("mail ID expected: %d, got: %d", test.expID, mail.ID)
+			}
+			if mail.TextBody == nil {
+				t.Fatal("full mail should be read")
+			}
+		})
+	}
+}
+
+func Test_WaitForCode(t *testing.T) {
+	var reads sync.Map
+	var checks int32
+	client := &ClientMock{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			if err := req.Context().Err(); err != nil {
+				return nil, err
+			}
+			query := req.URL.Query()
+			if query.Get("action") == "readMessage" {
+				n, _ := reads.LoadOrStore(query.Get("id"), new(int32))
+				count := atomic.AddInt32(n.(*int32), 1)
+				body := `{"id":639,"textBody":"Welcome aboard!"}`
+				switch {
+				case query.Get("id") == "640" && count == 1:
+					return &http.Response{StatusCode: 500, Body: ioutil.NopCloser(strings.NewReader(""))}, nil
+				case query.Get("id") == "640":
+					body = `{"id":640,"textBody":"Your code is ABC-123, not 999999"}`
+				}
+				return &http.Response{StatusCode: 200, Body: ioutil.NopCloser(strings.NewReader(body))}, nil
+			}
+			inbox := `[{"id":639}]`
+			if atomic.AddInt32(&checks, 1) > 1 {
+				inbox = `[{"id":639},{"id":640}]`
+			}
+			return &http.Response{StatusCode: 200, Body: ioutil.NopCloser(strings.NewReader(inbox))}, nil
+		},
+	}
+	mailbox, err := onesecmail.NewMailbox("foo", "1secmail.org", onesecmail.WithHTTPClient(client))
+	if err != nil {
+		t.Fatal("should not error")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	code, err := mailbox.WaitForCode(ctx, regexp.MustCompile(`[A-Z]{3}-\d{3}`), time.Millisecond)
+	if err != nil {
+		t.Fatalf("should not error: %v", err)
+	}
+	if code != "ABC-123" {
+		t.Fatalf("code expected: ABC-123, got: %s", code)
+	}
+	for id, exp := range map[string]int32{"639": 1, "640": 2} {
+		n, _ := reads.Load(id)
+		if got := atomic.LoadInt32(n.(*int32)); got != exp {
+			t.Fatalf("mail %s should be read %d times, got: %d", id, exp, got)
+		}
+	}
+}
+
+func Test_WaitForCode_Timeout(t *testing.T) {
+	mailbox, err := onesecmail.NewMailbox("foo", "1secmail.org", onesecmail.WithHTTPClient(readMessageClient(`[]`)))
+	if err != nil {
+		t.Fatal("should not error")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if _, err := mailbox.WaitForCode(ctx, nil, time.Millisecond); !errors.Is(err, onesecmail.ErrWaitTimeout) {
+		t.Fatalf("expected ErrWaitTimeout, got: %v", err)
+	}
+}
@@ -0,0 +1,136 @@
+package onesecmail
+
+import (
+	"context"
+	"time"
+)
+
+// watchBufferSize is the buffer size of the channel returned by Watch.
+const watchBufferSize = 16
+
+// WatchOptions configures WatchWithOptions.
+type WatchOptions struct {
+	// Interval is how often the inbox is checked. If it is zero or negative, a
+	// default of 5 seconds is used.
+	Interval time.Duration
+	// BufferSize is the buffer size of the returned channels. If it is zero or
+	// negative, a default of 16 is used.
+	BufferSize int
+	// MaxConsecutiveErrors is the number of consecutive failed checks of the inbox
+	// after which watching stops. If it is zero or negative, watching never stops
+	// because of errors.
+	MaxConsecutiveErrors int
+	// DedupWindow is how long a mail is remembered after it was sent. A mail still
+	// in the inbox after that is sent again. If it is zero or negative, mails are
+	// remembered until watching stops.
+	DedupWindow time.Duration
+}
+
+// Watch polls the inbox of a mailbox every interval, and sends each mail it has not
+// seen before on the returned channel, starting with the mails already in the inbox.
+// Mails are identified by their ID. Errors from checking the inbox are ignored and
+// the inbox is checked again on the next poll. The channel is closed once ctx is done.
+// If interval is zero or negative, a default of 5 seconds is used.
+func (m Mailbox) Watch(ctx context.Context, interval time.Duration) <-chan *Mail {
+	ch := make(chan *Mail, watchBufferSize)
+	go func() {
+		defer close(ch)
+		m.poll(ctx, WatchOptions{Interval: interval}, func(mail *Mail) bool {
+			select {
+			case ch <- mail:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}, func(err error) bool {
+			return true
+		})
+	}()
+	return ch
+}
+
+// WatchWithOptions is like Watch, but configured by opts, and sends the errors from
+// checking the inbox on the returned error channel. Once opts.MaxConsecutiveErrors
+// is reached, or ctx is done, both channels are closed.
+func (m Mailbox) WatchWithOptions(ctx context.Context, opts WatchOptions) (<-chan *Mail, <-chan error) {
+	if opts.BufferSize <= 0 {
+		opts.BufferSize = watchBufferSize
+	}
+	mailCh := make(chan *Mail, opts.BufferSize)
+	errCh := make(chan error, opts.BufferSize)
+	go func() {
+		defer close(mailCh)
+		defer close(errCh)
+		m.poll(ctx, opts, func(mail *Mail) bool {
+			select {
+			case mailCh <- mail:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		}, func(err error) bool {
+			select {
+			case errCh <- err:
+				return true
+			case <-ctx.Done():
+				return false
+			}
+		})
+	}()
+	return mailCh, errCh
+}
+
+// poll checks the inbox of a mailbox every opts.Interval, and calls onMail with each
+// mail it has not seen before, and onErr with each error from checking the inbox. It
+// returns once ctx is done, opts.MaxConsecutiveErrors is reached, or onMail or onErr
+// returns false.
+func (m Mailbox) poll(ctx context.Context, opts WatchOptions, onMail func(*Mail) bool, onErr func(error) bool) {
+	interval := opts.Interval
+	if interval <= 0 {
+		interval = defaultPollInterval
+	}
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	seen := make(map[int]time.Time)
+	consecutiveErrors := 0
+	for {
+		mails, err := m.CheckInbox(ctx)
+		switch {
+		case ctx.Err() != nil:
+			return
+		case err != nil:
+			consecutiveErrors++
+			if !onErr(err) {
+				return
+			}
+			if opts.MaxConsecutiveErrors > 0 && consecutiveErrors >= opts.MaxConsecutiveErrors {
+				return
+			}
+		default:
+			consecutiveErrors = 0
+			now := time.Now()
+			if opts.DedupWindow > 0 {
+				for id, sent := range seen {
+					if now.Sub(sent) >= opts.DedupWindow {
+						delete(seen, id)
+					}
+				}
+			}
+			for _, mail := range mails {
+				if _, ok := seen[mail.ID]; ok {
+					continue
+				}
+				if !onMail(mail) {
+					return
+				}
+				seen[mail.ID] = now
+			}
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,189 @@
+package onesecmail_test
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/z11i/onesecmail"
+)
+
+func Test_Watch(t *testing.T) {
+	client, _ := inboxSequenceClient(
+		`[]`,
+		`[{"id":639,"from":"someone@example.com","subject":"Some subject","date":"2018-06-08 14:33:55"}]`,
+		`[{"id":639,"from":"someone@example.com","subject":"Some subject","date":"2018-06-08 14:33:55"},{"id":640,"from":"someoneelse@example.com","subject":"Other subject","date":"2018-06-08 14:40:55"}]`,
+	)
+	mailbox, err := onesecmail.NewMailbox("foo", "1secmail.org", onesecmail.WithHTTPClient(client))
+	if err != nil {
+		t.Fatal("should not error")
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+
+	ch := mailbox.Watch(ctx, time.Millisecond)
+	var ids []int
+	for mail := range ch {
+		ids = append(ids, mail.ID)
+		if len(ids) == 2 {
+			cancel()
+		}
+	}
+	if len(ids) != 2 || ids[0] != 639 || ids[1] != 640 {
+		t.Fatalf("mail IDs expected: [639 640], got: %v", ids)
+	}
+}
+
+func Test_Watch_SlowConsumer(t *testing.T) {
+	client, _ := inboxSequenceClient(`[{"id":639,"from":"someone@example.com","subject":"Some subject","date":"2018-06-08 14:33:55"}]`)
+	mailbox, err := onesecmail.NewMailbox("foo", "1secmail.org", onesecmail.WithHTTPClient(client))
+	if err != nil {
+		t.Fatal("should not error")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	ch := mailbox.Watch(ctx, time.Millisecond)
+	time.Sleep(10 * time.Millisecond)
+	cancel()
+
+	// The channel must be closed after cancellation even though nothing was received.
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case _, ok := <-ch:
+			if !ok {
+				return
+			}
+		case <-timeout:
+			t.Fatal("channel should be closed after the context is cancelled")
+		}
+	}
+}
+
+// scriptedResponse is a response returned by scriptedClient. A response with status
+// code 0 fails with a network error.
+type scriptedResponse struct {
+	code int
+	body string
+}
+
+// scriptedClient returns the responses in order, repeating the last one.
+func scriptedClient(responses ...scriptedResponse) *ClientMock {
+	var calls int32
+	return &ClientMock{
+		DoFunc: func(req *http.Request) (*http.Response, error) {
+			n := int(atomic.AddInt32(&calls, 1)) - 1
+			if n >= len(responses) {
+				n = len(responses) - 1
+			}
+			if responses[n].code == 0 {
+				return nil, errors.New("network down")
+			}
+			return &http.Response{StatusCode: responses[n].code, Body: io.NopCloser(strings.NewReader(responses[n].body))}, nil
+		},
+	}
+}
+
+// drainWatch receives from mailCh and errCh until both are closed, failing the test
+// if that takes longer than a second.
+func drainWatch(t *testing.T, mailCh <-chan *onesecmail.Mail, errCh <-chan error) (ids []int, errs []error) {
+	t.Helper()
+	timeout := time.After(time.Second)
+	for mailCh != nil || errCh != nil {
+		select {
+		case mail, ok := <-mailCh:
+			if !ok {
+				mailCh = nil
+				continue
+			}
+			ids = append(ids, mail.ID)
+		case err, ok := <-errCh:
+			if !ok {
+				errCh = nil
+				continue
+			}
+			errs = append(errs, err)
+		case <-timeout:
+			t.Fatal("channels should be closed")
+		}
+	}
+	return ids, errs
+}
+
+func Test_WatchWithOptions_MaxConsecutiveErrors(t *testing.T) {
+	client := scriptedClient(
+		scriptedResponse{0, ""},
+		scriptedResponse{500, ""},
+		scriptedResponse{200, `[{"id":639}]`},
+		scriptedResponse{500, ""},
+		scriptedResponse{0, ""},
+		scriptedResponse{500, ""},
+		scriptedResponse{200, `[{"id":640}]`},
+	)
+	mailbox, err := onesecmail.NewMailbox("foo", "1secmail.org", onesecmail.WithHTTPClient(client))
+	if err != nil {
+		t.Fatal("should not error")
+	}
+
+	mailCh, errCh := mailbox.WatchWithOptions(context.Background(), onesecmail.WatchOptions{
+		Interval:             time.Millisecond,
+		MaxConsecutiveErrors: 3,
+	})
+	ids, errs := drainWatch(t, mailCh, errCh)
+	if len(ids) != 1 || ids[0] != 639 {
+		t.Fatalf("mail IDs expected: [639], got: %v", ids)
+	}
+	if len(errs) != 5 {
+		t.Fatalf("5 errors expected, got: %v", errs)
+	}
+	if !errors.Is(errs[1], onesecmail.ErrHTTPStatus) {
+		t.Fatalf("expected ErrHTTPStatus, got: %v", errs[1])
+	}
+}
+
+func Test_WatchWithOptions_Cancel(t *testing.T) {
+	client, _ := inboxSequenceClient(`[{"id":639}]`)
+	mailbox, err := onesecmail.NewMailbox("foo", "1secmail.org", onesecmail.WithHTTPClient(client))
+	if err != nil {
+		t.Fatal("should not error")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	mailCh, errCh := mailbox.WatchWithOptions(ctx, onesecmail.WatchOptions{Interval: time.Millisecond, BufferSize: 1})
+	if mail := <-mailCh; mail.ID != 639 {
+		t.Fatalf("mail ID expected: 639, got: %d", mail.ID)
+	}
+	cancel()
+	if ids, errs := drainWatch(t, mailCh, errCh); len(ids) != 0 || len(errs) != 0 {
+		t.Fatalf("nothing expected after cancellation, got: %v, %v", ids, errs)
+	}
+}
+
+func Test_WatchWithOptions_DedupWindow(t *testing.T) {
+	client, _ := inboxSequenceClient(`[{"id":639}]`)
+	mailbox, err := onesecmail.NewMailbox("foo", "1secmail.org", onesecmail.WithHTTPClient(client))
+	if err != nil {
+		t.Fatal("should not error")
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	mailCh, errCh := mailbox.WatchWithOptions(ctx, onesecmail.WatchOptions{
+		Interval:    time.Millisecond,
+		DedupWindow: 5 * time.Millisecond,
+	})
+	for i := 0; i < 2; i++ {
+		select {
+		case mail := <-mailCh:
+			if mail.ID != 639 {
+				t.Fatalf("mail ID expected: 639, got: %d", mail.ID)
+			}
+		case <-time.After(time.Second):
+			t.Fatal("mail should be sent again after the dedup window")
+		}
+	}
+	cancel()
+	drainWatch(t, mailCh, errCh)
+}